@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"nabievarthur/GOsuslugiXML/internal/service"
+)
+
+// коды выхода
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Использование:
+  cli prepare [-o файл] [-split] [-chunk N] [-keep-docs] [-config файл] выгрузка.xml
+        подготовить строки запроса в ИБД-Ф (по умолчанию в stdout);
+        с -split — частями по N строк, каждая в свой файл файл_1, файл_2...
+  cli match -xml выгрузка.xml [-o отчет.xlsx|папка] [-name шаблон]
+            [-format xlsx|csv|json|html] [-config файл] [-extra] [-v]
+            [-missing строки.txt] ответ.xls
+        сравнить ответ ИБД-Ф с выгрузкой и создать отчет;
+        ответ: .xls, .xlsx, HTML или CSV/TSV
+`
+
+func main() {
+	// Ctrl+C прерывает разбор и сравнение, недописанный отчет удаляется
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "prepare":
+		return runPrepare(ctx, args[1:], stdout, stderr)
+	case "match":
+		return runMatch(ctx, args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "неизвестная команда: %s\n\n%s", args[0], usage)
+		return exitUsage
+	}
+}
+
+// ===== PREPARE =====
+
+func runPrepare(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("prepare", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("o", "", "файл для строк запроса (по умолчанию stdout)")
+	split := fs.Bool("split", false, "разбить строки на части (размер из настроек chunk)")
+	chunk := fs.Int("chunk", 0, "строк в части, включает -split (по умолчанию из настроек chunk.size)")
+	keepDocs := fs.Bool("keep-docs", false, "не разделять строки одного документа между частями, включает -split")
+	configPath := fs.String("config", "", "файл настроек JSON (по умолчанию из профиля пользователя)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprint(stderr, "prepare: нужно указать один XML файл\n\n"+usage)
+		return exitUsage
+	}
+	if *chunk < 0 {
+		fmt.Fprint(stderr, "prepare: -chunk должен быть больше нуля\n")
+		return exitUsage
+	}
+
+	if *split || *chunk > 0 || *keepDocs {
+		return runPrepareChunks(ctx, fs.Arg(0), *out, *chunk, *keepDocs, *configPath, stdout, stderr)
+	}
+
+	res, err := service.NewXMLParser().ParseXMLToFileContext(ctx, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка разбора XML: %v\n", err)
+		return exitError
+	}
+
+	if *out == "" {
+		fmt.Fprint(stdout, res)
+		return exitOK
+	}
+
+	if err := os.WriteFile(*out, []byte(res), 0o644); err != nil {
+		fmt.Fprintf(stderr, "Ошибка записи файла: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// runPrepareChunks пишет строки запроса частями: в stdout через пустую
+// строку или в файлы out_1, out_2... рядом с out
+func runPrepareChunks(ctx context.Context, xmlFile, out string, size int, keepDocs bool, configPath string, stdout, stderr io.Writer) int {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка чтения настроек: %v\n", err)
+		return exitError
+	}
+	opts := cfg.Chunk
+	if size > 0 {
+		opts.Size = size
+	}
+	if keepDocs {
+		opts.KeepDocuments = true
+	}
+
+	batch, err := service.NewXMLParserWithConfig(cfg).LoadBatchContext(ctx, xmlFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка разбора XML: %v\n", err)
+		return exitError
+	}
+	chunks := batch.Chunks(opts)
+
+	if out == "" {
+		for i, lines := range chunks {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			fmt.Fprintln(stdout, strings.Join(lines, "\n"))
+		}
+		return exitOK
+	}
+
+	ext := filepath.Ext(out)
+	base := strings.TrimSuffix(out, ext)
+	for i, lines := range chunks {
+		path := fmt.Sprintf("%s_%d%s", base, i+1, ext)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			fmt.Fprintf(stderr, "Ошибка записи файла: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "Часть %d (%d строк): %s\n", i+1, len(lines), path)
+	}
+	return exitOK
+}
+
+// ===== MATCH =====
+
+func runMatch(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("match", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	xmlFile := fs.String("xml", "", "XML выгрузка с Госуслуг")
+	out := fs.String("o", "", "файл отчета (с расширением) или папка (без расширения, создается); по умолчанию рядом с файлом ИБД-Ф; существующий файл не перезаписывается")
+	name := fs.String("name", "", "шаблон имени отчета: {xml}, {date}, {time}, {batch} (по умолчанию "+service.DefaultNameTemplate+")")
+	configPath := fs.String("config", "", "файл настроек JSON (по умолчанию из профиля пользователя)")
+	extra := fs.Bool("extra", false, "добавить в отчет сведения из XML (место рождения, адрес, документ...)")
+	format := fs.String("format", "", "формат отчета: "+strings.Join(service.ReportFormats(), ", ")+" (по умолчанию по расширению -o или xlsx)")
+	verbose := fs.Bool("v", false, "подробный журнал в stderr (персональные данные маскируются, см. log в настройках)")
+	missingOut := fs.String("missing", "", "файл для строк запроса, на которые нет ответа ИБД-Ф")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *xmlFile == "" || fs.NArg() != 1 {
+		fmt.Fprint(stderr, "match: нужно указать -xml и один файл ответа ИБД-Ф\n\n"+usage)
+		return exitUsage
+	}
+	xlsFile := fs.Arg(0)
+
+	if *format == "" {
+		*format = service.FormatFromPath(*out)
+	}
+	w, err := service.NewReportWriter(*format)
+	if err != nil {
+		fmt.Fprintf(stderr, "match: %v\n", err)
+		return exitUsage
+	}
+	// -o отчет.csv -format json — скорее всего ошибка в команде
+	if ext := filepath.Ext(*out); ext != "" && !isDir(*out) && service.FormatFromPath(*out) != service.FormatFromPath(w.Extension()) {
+		fmt.Fprintf(stderr, "match: расширение %s не соответствует формату %s\n", ext, *format)
+		return exitUsage
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка чтения настроек: %v\n", err)
+		return exitError
+	}
+
+	parser := service.NewXMLParserWithConfig(cfg)
+	logOpts := cfg.Log
+	if *verbose {
+		logOpts.Level = "debug"
+	}
+	parser.SetLogger(service.NewLogger(stderr, logOpts))
+
+	batch, err := parser.LoadBatchContext(ctx, *xmlFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка чтения XML: %v\n", err)
+		return exitError
+	}
+
+	xlsRows, err := parser.ReadXLSFileContext(ctx, xlsFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка чтения XLS: %v\n", err)
+		return exitError
+	}
+
+	res, err := batch.CompareContext(ctx, xlsRows, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка сравнения: %v\n", err)
+		return exitError
+	}
+
+	opts := service.ReportOptions{
+		ExtraColumns:  *extra,
+		PositiveRules: cfg.PositiveRules,
+		Format:        *format,
+		OutPath:       *out,
+		Dir:           cfg.ReportDir,
+		NameTemplate:  *name,
+		XMLFile:       *xmlFile,
+	}
+	if opts.NameTemplate == "" {
+		opts.NameTemplate = cfg.ReportName
+	}
+	report, err := service.CreateReportContext(ctx, xlsFile, res, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "Ошибка создания файла: %v\n", err)
+		return exitError
+	}
+
+	if *missingOut != "" {
+		lines := strings.Join(res.MissingLines(), "\n")
+		if lines != "" {
+			lines += "\n"
+		}
+		if err := os.WriteFile(*missingOut, []byte(lines), 0o644); err != nil {
+			fmt.Fprintf(stderr, "Ошибка записи файла: %v\n", err)
+			return exitError
+		}
+	}
+
+	fmt.Fprintln(stdout, "Новый файл успешно создан:", report.Path)
+	fmt.Fprintln(stdout, report)
+	summary := res.Summary()
+	fmt.Fprintln(stdout, summary)
+	if summary.Orphans > 0 {
+		fmt.Fprintf(stderr, "Внимание: %d строк ИБД-Ф нет в XML — возможно, ответ из другой партии\n", summary.Orphans)
+	}
+	return exitOK
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// настройки из указанного файла или из профиля пользователя
+func loadConfig(path string) (*service.Config, error) {
+	if path == "" {
+		return service.LoadUserConfig()
+	}
+	return service.LoadConfig(path)
+}
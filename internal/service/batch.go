@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ===== ПАРТИЯ (РАЗОБРАННАЯ ВЫГРУЗКА) =====
+
+// Batch — один раз разобранная XML выгрузка: документы, строки запроса
+// в ИБД-Ф и индекс для сравнения. Все сравнения в рамках сессии работают
+// с этим снимком, даже если файл на диске уже изменился.
+type Batch struct {
+	FileName  string
+	Documents []Document
+	Lines     []string
+
+	norm  NormalizeOptions
+	index map[string][]int // ключ ФИО+дата -> индексы в Documents
+}
+
+// LoadBatch читает и разбирает XML выгрузку из файла
+func (x *XmlParser) LoadBatch(filename string) (*Batch, error) {
+	return x.LoadBatchContext(context.Background(), filename)
+}
+
+// LoadBatchContext — LoadBatch с отменой через ctx
+func (x *XmlParser) LoadBatchContext(ctx context.Context, filename string) (*Batch, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	batch, err := x.readBatch(ctx, file, size)
+	if err != nil {
+		x.log.Error("не удалось разобрать XML", "file", filepath.Base(filename), "err", err)
+		return nil, err
+	}
+	batch.FileName = filename
+	x.log.Info("разобрана выгрузка XML", "file", filepath.Base(filename),
+		"documents", len(batch.Documents), "lines", len(batch.Lines))
+	return batch, nil
+}
+
+// ReadBatch разбирает XML выгрузку из r
+func (x *XmlParser) ReadBatch(r io.Reader) (*Batch, error) {
+	return x.ReadBatchContext(context.Background(), r)
+}
+
+// ReadBatchContext — ReadBatch с отменой и ходом выполнения через ctx
+func (x *XmlParser) ReadBatchContext(ctx context.Context, r io.Reader) (*Batch, error) {
+	return x.readBatch(ctx, r, 0)
+}
+
+// size — размер XML для доли выполненного, 0 — неизвестен
+func (x *XmlParser) readBatch(ctx context.Context, r io.Reader, size int64) (*Batch, error) {
+	batch := &Batch{
+		norm:  x.cfg.Normalize,
+		index: make(map[string][]int),
+	}
+
+	counter := &countingReader{r: r}
+	err := DecodeDocumentsContext(ctx, counter, func(doc Document) error {
+		batch.Documents = append(batch.Documents, doc)
+		progress(ctx, Progress{Stage: StageParse, Done: len(batch.Documents), Bytes: counter.n, Size: size})
+		batch.Lines = append(batch.Lines, documentLines(doc)...)
+		idx := len(batch.Documents) - 1
+		for _, key := range documentKeys(doc, batch.norm) {
+			// разные ФИО одного документа могут дать один ключ после нормализации
+			if docs := batch.index[key]; len(docs) == 0 || docs[len(docs)-1] != idx {
+				batch.index[key] = append(docs, idx)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// Match проставляет номера документов и сведения из XML в копии строк XLS.
+// Если ключу соответствует несколько документов (дубли, однофамильцы),
+// строка помечается как неоднозначная и получает все номера.
+// Для строк без точного совпадения вторым проходом ищутся похожие документы.
+func (b *Batch) Match(xlsRows []XLSRow) []XLSRow {
+	matched, _ := b.MatchContext(context.Background(), xlsRows)
+	return matched
+}
+
+// MatchContext — Match с отменой и ходом выполнения через ctx
+func (b *Batch) MatchContext(ctx context.Context, xlsRows []XLSRow) ([]XLSRow, error) {
+	matched := make([]XLSRow, len(xlsRows))
+	copy(matched, xlsRows)
+
+	var unmatched []int
+	for i, xlsRow := range matched {
+		if err := progressStep(ctx, StageMatch, i+1, len(matched)); err != nil {
+			return nil, err
+		}
+
+		docs := b.index[rowKey(xlsRow, b.norm)]
+		switch len(docs) {
+		case 0:
+			unmatched = append(unmatched, i)
+		case 1:
+			doc := b.Documents[docs[0]]
+			matched[i].DocumentNumber = doc.DocNumber
+			matched[i].Details = doc.Details()
+		default:
+			numbers := make([]string, len(docs))
+			for j, idx := range docs {
+				numbers[j] = b.Documents[idx].DocNumber
+			}
+			matched[i].DocumentNumber = strings.Join(numbers, ", ")
+			matched[i].Ambiguous = len(docs)
+		}
+	}
+
+	// нечеткое сравнение для оставшихся строк
+	if len(unmatched) > 0 {
+		entries := b.fuzzyEntries()
+		for n, i := range unmatched {
+			if err := progressStep(ctx, StageFuzzy, n+1, len(unmatched)); err != nil {
+				return nil, err
+			}
+			matched[i].Candidates = b.fuzzyCandidates(matched[i], entries)
+		}
+	}
+
+	return matched, nil
+}
+
+// MatchResult — результат сравнения партии с ответом ИБД-Ф
+type MatchResult struct {
+	Rows      []XLSRow          // строки ИБД-Ф с проставленными документами
+	Missing   []MissingDocument // документы XML, на строки которых нет ответа
+	Documents []DocumentResult  // документы партии (вкладки) и их строки ИБД-Ф
+
+	norm NormalizeOptions // правила сравнения ФИО партии
+}
+
+// DocumentResult — документ XML и все строки ответа по его ФИО,
+// включая прежние (CPLastFIO)
+type DocumentResult struct {
+	DocNumber    string
+	FIO          string   // текущее ФИО
+	Rows         []int    // индексы в MatchResult.Rows, относящиеся только к этому документу
+	Ambiguous    []int    // строки, ключ которых совпал и с другими документами (однофамильцы)
+	MissingLines []string // строки запроса без ответа
+
+	name FIO // текущее ФИО по частям
+}
+
+// MissingDocument — документ XML, для части или всех строк запроса
+// которого в ответе ИБД-Ф нет строки
+type MissingDocument struct {
+	DocNumber string
+	FIO       string
+	Lines     []string // строки запроса для повторной отправки
+}
+
+// Compare сравнивает строки ИБД-Ф с партией в обе стороны: проставляет
+// документы строкам и ищет документы, оставшиеся без ответа.
+// sent — строки запроса, которые отправлялись в ИБД-Ф (например, одна
+// вкладка); без ответа ищутся только среди них. nil — вся партия.
+func (b *Batch) Compare(xlsRows []XLSRow, sent []string) *MatchResult {
+	res, _ := b.CompareContext(context.Background(), xlsRows, sent)
+	return res
+}
+
+// CompareContext — Compare с отменой и ходом выполнения через ctx
+func (b *Batch) CompareContext(ctx context.Context, xlsRows []XLSRow, sent []string) (*MatchResult, error) {
+	rows, err := b.MatchContext(ctx, xlsRows)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := b.documents(ctx, xlsRows, sent)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MatchResult{Rows: rows, Documents: docs, norm: b.norm}
+	for _, doc := range res.Documents {
+		if len(doc.MissingLines) > 0 {
+			res.Missing = append(res.Missing, MissingDocument{
+				DocNumber: doc.DocNumber,
+				FIO:       doc.FIO,
+				Lines:     doc.MissingLines,
+			})
+		}
+	}
+	return res, nil
+}
+
+// MissingLines — все строки запроса без ответа, для повторной отправки
+func (r *MatchResult) MissingLines() []string {
+	var lines []string
+	for _, doc := range r.Missing {
+		lines = append(lines, doc.Lines...)
+	}
+	return lines
+}
+
+// documents собирает для каждого документа строки ответа по всем его ключам
+// и строки запроса, для которых в ответе нет строки с тем же ключом
+func (b *Batch) documents(ctx context.Context, xlsRows []XLSRow, sent []string) ([]DocumentResult, error) {
+	answered := make(map[string]bool, len(xlsRows))
+	rowsByDoc := make(map[int][]int)      // индекс документа -> строки ИБД-Ф
+	ambiguousByDoc := make(map[int][]int) // строки, подходящие нескольким документам
+	for i, row := range xlsRows {
+		key := rowKey(row, b.norm)
+		answered[key] = true
+		docs := b.index[key]
+		for _, idx := range docs {
+			if len(docs) > 1 {
+				ambiguousByDoc[idx] = append(ambiguousByDoc[idx], i)
+			} else {
+				rowsByDoc[idx] = append(rowsByDoc[idx], i)
+			}
+		}
+	}
+
+	var inScope map[string]bool
+	if sent != nil {
+		inScope = make(map[string]bool, len(sent))
+		for _, line := range sent {
+			inScope[line] = true
+		}
+	}
+
+	var docs []DocumentResult
+	for idx, doc := range b.Documents {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// строки и ключи идут в порядке FullNames; без даты ключей нет
+		lines := documentLines(doc)
+		keys := documentKeys(doc, b.norm)
+
+		sentAny := false
+		var lost []string
+		for i, line := range lines {
+			if inScope != nil && !inScope[line] {
+				continue
+			}
+			sentAny = true
+			if keys == nil || !answered[keys[i]] {
+				lost = append(lost, line)
+			}
+		}
+		// документ из другой вкладки
+		if !sentAny {
+			continue
+		}
+
+		p := doc.RequestInfo.ConvictionPerson
+		docs = append(docs, DocumentResult{
+			DocNumber:    doc.DocNumber,
+			FIO:          strings.Join([]string{p.CPSurname, p.CPName, p.CPPatronymic}, " "),
+			Rows:         rowsByDoc[idx],
+			Ambiguous:    ambiguousByDoc[idx],
+			MissingLines: lost,
+			name:         FIO{Surname: p.CPSurname, Name: p.CPName, Patronymic: p.CPPatronymic},
+		})
+	}
+	return docs, nil
+}
+
+// ключи для поиска документа: Фамилия_Имя_Отчество_Год_Месяц_День,
+// ФИО и дата предварительно нормализуются
+func documentKeys(doc Document, norm NormalizeOptions) []string {
+	p := doc.RequestInfo.ConvictionPerson
+	date, err := ParseDate(p.CPBirthday)
+	if err != nil {
+		return nil
+	}
+
+	// по ключу на каждое полное ФИО, включая прежние
+	var keys []string
+	for _, fio := range p.FullNames() {
+		keys = append(keys, matchKey(norm.FIO(fio), date.Key()))
+	}
+
+	return keys
+}
+
+func rowKey(xlsRow XLSRow, norm NormalizeOptions) string {
+	fio := FIO{Surname: xlsRow.Surname, Name: xlsRow.Name, Patronymic: xlsRow.Patronymic}
+
+	dateKey := xlsRow.BirthYear + "_" + xlsRow.BirthMonth + "_" + xlsRow.BirthDay
+	if date, err := ParseDateParts(xlsRow.BirthYear, xlsRow.BirthMonth, xlsRow.BirthDay); err == nil {
+		dateKey = date.Key()
+	}
+
+	return matchKey(norm.FIO(fio), dateKey)
+}
+
+func matchKey(fio FIO, dateKey string) string {
+	return fmt.Sprintf("%s_%s_%s_%s", fio.Surname, fio.Name, fio.Patronymic, dateKey)
+}
+
+// MatchSummary — итоги сравнения для вывода пользователю
+type MatchSummary struct {
+	Rows      int // всего строк ИБД-Ф
+	Matched   int // сопоставлены с одним документом
+	Ambiguous int // ключ совпал с несколькими документами
+	Fuzzy     int // есть кандидаты нечеткого сравнения
+	Orphans   int // нет в XML: без документа и без кандидатов
+
+	MissingDocuments int // документы XML без ответа ИБД-Ф
+	MissingLines     int // строки запроса без ответа
+}
+
+func Summarize(xlsRows []XLSRow) MatchSummary {
+	s := MatchSummary{Rows: len(xlsRows)}
+	for _, row := range xlsRows {
+		switch row.Status() {
+		case StatusMatched:
+			s.Matched++
+		case StatusReview:
+			s.Fuzzy++
+		case StatusOrphan:
+			s.Orphans++
+		default:
+			s.Ambiguous++
+		}
+	}
+	return s
+}
+
+// Summary — итоги по строкам и документам без ответа
+func (r *MatchResult) Summary() MatchSummary {
+	s := Summarize(r.Rows)
+	s.MissingDocuments = len(r.Missing)
+	s.MissingLines = len(r.MissingLines())
+	return s
+}
+
+func (s MatchSummary) String() string {
+	text := fmt.Sprintf("Строк: %d, сопоставлено: %d, неоднозначно: %d, требует проверки: %d, нет в XML: %d",
+		s.Rows, s.Matched, s.Ambiguous, s.Fuzzy, s.Orphans)
+	if s.MissingDocuments > 0 {
+		text += fmt.Sprintf("; нет ответа ИБД-Ф: %d док. (%d строк)", s.MissingDocuments, s.MissingLines)
+	}
+	return text
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// ===== СТАРЫЙ ФОРМАТ EXCEL (.xls, BIFF8) =====
+
+// сигнатура составного файла (CFB/OLE), в котором хранится .xls
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// errNoWorkbook — CFB файл без книги Excel (например, зашифрованный .xlsx)
+var errNoWorkbook = errors.New("в файле нет книги Excel (поток Workbook)")
+
+// типы записей BIFF8, которые нужны для чтения значений
+const (
+	biffFormula    = 0x0006
+	biffEOF        = 0x000A
+	biffFilePass   = 0x002F
+	biffContinue   = 0x003C
+	biffBoundSheet = 0x0085
+	biffMulRK      = 0x00BD
+	biffRString    = 0x00D6
+	biffSST        = 0x00FC
+	biffLabelSST   = 0x00FD
+	biffNumber     = 0x0203
+	biffLabel      = 0x0204
+	biffBoolErr    = 0x0205
+	biffString     = 0x0207
+	biffRK         = 0x027E
+	biffBOF        = 0x0809
+)
+
+type biffRecord struct {
+	typ  uint16
+	data []byte
+	pos  int // смещение записи в потоке
+}
+
+// readXLSRows читает первый лист настоящего .xls (BIFF8) в виде строк таблицы
+func readXLSRows(filename string) ([][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stream, err := workbookStream(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseBIFF(stream)
+}
+
+// workbookStream достает поток Workbook из составного файла
+func workbookStream(r io.ReaderAt) ([]byte, error) {
+	doc, err := mscfb.New(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		switch entry.Name {
+		case "Workbook":
+			return io.ReadAll(entry)
+		case "Book":
+			return nil, fmt.Errorf("формат Excel 5.0/95 не поддерживается, пересохраните файл")
+		}
+	}
+	return nil, errNoWorkbook
+}
+
+func splitBIFFRecords(stream []byte) []biffRecord {
+	var records []biffRecord
+	for pos := 0; pos+4 <= len(stream); {
+		typ := binary.LittleEndian.Uint16(stream[pos:])
+		size := int(binary.LittleEndian.Uint16(stream[pos+2:]))
+		end := min(pos+4+size, len(stream))
+		records = append(records, biffRecord{typ: typ, data: stream[pos+4 : end], pos: pos})
+		pos = end
+	}
+	return records
+}
+
+func parseBIFF(stream []byte) ([][]string, error) {
+	records := splitBIFFRecords(stream)
+
+	var sst []string
+	sheetPos := -1
+
+	// --- глобальная часть книги: общие строки и список листов ---
+	for i := 0; i < len(records); i++ {
+		rec := records[i]
+		switch rec.typ {
+		case biffFilePass:
+			return nil, fmt.Errorf("файл .xls защищен паролем")
+		case biffSST:
+			segments := [][]byte{rec.data}
+			for i+1 < len(records) && records[i+1].typ == biffContinue {
+				i++
+				segments = append(segments, records[i].data)
+			}
+			var err error
+			if sst, err = parseSST(segments); err != nil {
+				return nil, err
+			}
+		case biffBoundSheet:
+			// первый обычный лист (тип 0)
+			if sheetPos < 0 && len(rec.data) >= 6 && rec.data[5] == 0 {
+				sheetPos = int(binary.LittleEndian.Uint32(rec.data))
+			}
+		case biffEOF:
+			i = len(records)
+		}
+	}
+	if sheetPos < 0 {
+		return nil, fmt.Errorf("нет доступных листов в файле")
+	}
+
+	// --- первый лист: ячейки ---
+	cells := make(map[int]map[int]string)
+	set := func(row, col int, value string) {
+		if cells[row] == nil {
+			cells[row] = make(map[int]string)
+		}
+		cells[row][col] = value
+	}
+	// ячейка по строке и колонке из начала записи
+	setCell := func(d []byte, value string) {
+		row, col := cellPos(d)
+		set(row, col, value)
+	}
+
+	started := false
+	pendingRow, pendingCol := -1, -1 // FORMULA, значение которой в следующей записи STRING
+
+	for _, rec := range records {
+		if rec.pos < sheetPos {
+			continue
+		}
+		if !started {
+			if rec.typ != biffBOF {
+				return nil, fmt.Errorf("поврежденный .xls: нет начала листа")
+			}
+			started = true
+			continue
+		}
+
+		d := rec.data
+		switch rec.typ {
+		case biffEOF:
+			return collectCells(cells), nil
+
+		case biffLabelSST:
+			if len(d) < 10 {
+				continue
+			}
+			idx := int(binary.LittleEndian.Uint32(d[6:]))
+			if idx < len(sst) {
+				setCell(d, sst[idx])
+			}
+
+		case biffLabel, biffRString:
+			if len(d) < 8 {
+				continue
+			}
+			s := readShortUnicode(d[6:])
+			setCell(d, s)
+
+		case biffNumber:
+			if len(d) < 14 {
+				continue
+			}
+			setCell(d, formatNumber(math.Float64frombits(binary.LittleEndian.Uint64(d[6:]))))
+
+		case biffRK:
+			if len(d) < 10 {
+				continue
+			}
+			setCell(d, formatNumber(decodeRK(binary.LittleEndian.Uint32(d[6:]))))
+
+		case biffMulRK:
+			if len(d) < 6 {
+				continue
+			}
+			row := int(binary.LittleEndian.Uint16(d))
+			col := int(binary.LittleEndian.Uint16(d[2:]))
+			for p := 4; p+6 <= len(d)-2; p += 6 {
+				set(row, col, formatNumber(decodeRK(binary.LittleEndian.Uint32(d[p+2:]))))
+				col++
+			}
+
+		case biffBoolErr:
+			if len(d) < 8 {
+				continue
+			}
+			if d[7] == 0 {
+				setCell(d, formatBool(d[6]))
+			}
+
+		case biffFormula:
+			if len(d) < 14 {
+				continue
+			}
+			row, col := cellPos(d)
+			result := d[6:14]
+			if result[6] != 0xFF || result[7] != 0xFF {
+				set(row, col, formatNumber(math.Float64frombits(binary.LittleEndian.Uint64(result))))
+				continue
+			}
+			switch result[0] {
+			case 0: // строка — в следующей записи STRING
+				pendingRow, pendingCol = row, col
+			case 1:
+				set(row, col, formatBool(result[2]))
+			}
+
+		case biffString:
+			if pendingRow >= 0 {
+				s := readShortUnicode(d)
+				set(pendingRow, pendingCol, s)
+				pendingRow, pendingCol = -1, -1
+			}
+		}
+	}
+
+	return collectCells(cells), nil
+}
+
+func cellPos(d []byte) (int, int) {
+	return int(binary.LittleEndian.Uint16(d)), int(binary.LittleEndian.Uint16(d[2:]))
+}
+
+// collectCells превращает разреженные ячейки в строки таблицы
+func collectCells(cells map[int]map[int]string) [][]string {
+	maxRow := -1
+	for r := range cells {
+		maxRow = max(maxRow, r)
+	}
+
+	rows := make([][]string, maxRow+1)
+	for r, cols := range cells {
+		maxCol := -1
+		for c := range cols {
+			maxCol = max(maxCol, c)
+		}
+		row := make([]string, maxCol+1)
+		for c, v := range cols {
+			row[c] = v
+		}
+		rows[r] = row
+	}
+	return rows
+}
+
+// decodeRK раскрывает компактное число RK
+func decodeRK(rk uint32) float64 {
+	var v float64
+	if rk&0x02 != 0 {
+		v = float64(int32(rk) >> 2)
+	} else {
+		v = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		v /= 100
+	}
+	return v
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatBool(b byte) string {
+	if b != 0 {
+		return "ИСТИНА"
+	}
+	return "ЛОЖЬ"
+}
+
+// readShortUnicode читает XLUnicodeString: длина, флаги, символы
+func readShortUnicode(d []byte) string {
+	if len(d) < 3 {
+		return ""
+	}
+	cch := int(binary.LittleEndian.Uint16(d))
+	flags := d[2]
+	pos := 3
+
+	if flags&0x08 != 0 { // rich text
+		pos += 2
+	}
+	if flags&0x04 != 0 { // расширенные данные
+		pos += 4
+	}
+
+	if flags&0x01 != 0 {
+		end := min(pos+cch*2, len(d))
+		return decodeUTF16(d[pos:end])
+	}
+	end := min(pos+cch, len(d))
+	return decodeLatin1(d[pos:end])
+}
+
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// сжатые строки BIFF8 — младшие байты UTF-16, т.е. Latin-1
+func decodeLatin1(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// ===== ТАБЛИЦА ОБЩИХ СТРОК (SST) =====
+
+// sstReader читает данные SST, разбитые на записи CONTINUE
+type sstReader struct {
+	segments [][]byte
+	seg      int
+	pos      int
+}
+
+var errSSTShort = errors.New("поврежденный .xls: таблица строк обрывается")
+
+// next переходит к следующей записи CONTINUE, если текущая закончилась
+func (r *sstReader) next() bool {
+	for r.seg < len(r.segments) && r.pos >= len(r.segments[r.seg]) {
+		r.seg++
+		r.pos = 0
+	}
+	return r.seg < len(r.segments)
+}
+
+// remaining — сколько байт осталось до конца SST
+func (r *sstReader) remaining() int {
+	n := 0
+	for i := r.seg; i < len(r.segments); i++ {
+		n += len(r.segments[i])
+	}
+	if r.seg < len(r.segments) {
+		n -= r.pos
+	}
+	return n
+}
+
+// bytes читает n байт служебных данных, которые могут пересекать CONTINUE.
+// Длина берется из файла, поэтому проверяется до выделения памяти.
+func (r *sstReader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > r.remaining() {
+		return nil, errSSTShort
+	}
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		if !r.next() {
+			return nil, errSSTShort
+		}
+		seg := r.segments[r.seg]
+		take := min(n-len(out), len(seg)-r.pos)
+		out = append(out, seg[r.pos:r.pos+take]...)
+		r.pos += take
+	}
+	return out, nil
+}
+
+// skip пропускает n байт (форматирование rich text, фонетика)
+func (r *sstReader) skip(n int) error {
+	if n < 0 || n > r.remaining() {
+		return errSSTShort
+	}
+	for n > 0 {
+		r.next()
+		take := min(n, len(r.segments[r.seg])-r.pos)
+		r.pos += take
+		n -= take
+	}
+	return nil
+}
+
+func (r *sstReader) uint16() (int, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint16(b)), nil
+}
+
+func (r *sstReader) uint32() (int, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint32(b)), nil
+}
+
+// chars читает cch символов; каждая запись CONTINUE, на которую приходятся
+// символы, начинается с байта флагов (1 или 2 байта на символ) — в том числе
+// когда заголовок строки закончился ровно на границе записи
+func (r *sstReader) chars(cch int, high bool) (string, error) {
+	u := make([]uint16, 0, cch)
+	for cch > 0 {
+		if r.seg >= len(r.segments) {
+			return "", errSSTShort
+		}
+		seg := r.segments[r.seg]
+		if r.pos >= len(seg) {
+			r.seg++
+			r.pos = 0
+			if r.seg >= len(r.segments) || len(r.segments[r.seg]) == 0 {
+				return "", errSSTShort
+			}
+			high = r.segments[r.seg][0]&0x01 != 0
+			r.pos = 1
+			continue
+		}
+
+		if high {
+			n := min(cch, (len(seg)-r.pos)/2)
+			if n == 0 { // половина символа в конце записи — данные повреждены
+				return "", errSSTShort
+			}
+			for i := 0; i < n; i++ {
+				u = append(u, binary.LittleEndian.Uint16(seg[r.pos+i*2:]))
+			}
+			r.pos += n * 2
+			cch -= n
+		} else {
+			n := min(cch, len(seg)-r.pos)
+			for i := 0; i < n; i++ {
+				u = append(u, uint16(seg[r.pos+i]))
+			}
+			r.pos += n
+			cch -= n
+		}
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+func parseSST(segments [][]byte) ([]string, error) {
+	r := &sstReader{segments: segments}
+
+	if _, err := r.uint32(); err != nil { // всего ссылок на строки
+		return nil, err
+	}
+	unique, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	sst := make([]string, 0, min(unique, 1<<16))
+	for i := 0; i < unique; i++ {
+		cch, err := r.uint16()
+		if err != nil {
+			return nil, err
+		}
+		flagsByte, err := r.bytes(1)
+		if err != nil {
+			return nil, err
+		}
+		flags := flagsByte[0]
+
+		runs, ext := 0, 0
+		if flags&0x08 != 0 {
+			if runs, err = r.uint16(); err != nil {
+				return nil, err
+			}
+		}
+		if flags&0x04 != 0 {
+			if ext, err = r.uint32(); err != nil {
+				return nil, err
+			}
+		}
+
+		s, err := r.chars(cch, flags&0x01 != 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.skip(runs*4 + ext); err != nil {
+			return nil, err
+		}
+		sst = append(sst, s)
+	}
+	return sst, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+// ===== СБОРКА ПОТОКА BIFF8 ДЛЯ ТЕСТОВ =====
+
+func le(values ...any) []byte {
+	var buf bytes.Buffer
+	for _, v := range values {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+func biffRec(typ uint16, data []byte) []byte {
+	return append(le(typ, uint16(len(data))), data...)
+}
+
+func utf16LE(s string) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, le(u)...)
+	}
+	return b
+}
+
+// строка SST в UTF-16
+func sstString(s string) []byte {
+	return append(le(uint16(len([]rune(s))), uint8(1)), utf16LE(s)...)
+}
+
+// workbook собирает книгу: глобальная часть с SST (и CONTINUE) и один лист
+func workbook(sst [][]byte, cells []byte) []byte {
+	global := func(offset uint32) []byte {
+		var g []byte
+		g = append(g, biffRec(biffBOF, le(uint16(0x600), uint16(5), uint32(0), uint32(0), uint32(0)))...)
+		g = append(g, biffRec(biffSST, sst[0])...)
+		for _, seg := range sst[1:] {
+			g = append(g, biffRec(biffContinue, seg)...)
+		}
+		g = append(g, biffRec(biffBoundSheet, append(le(offset, uint8(0), uint8(0), uint8(5), uint8(0)), "Sheet"...))...)
+		return append(g, biffRec(biffEOF, nil)...)
+	}
+	g := global(0)
+	g = global(uint32(len(g)))
+
+	sheet := biffRec(biffBOF, le(uint16(0x600), uint16(0x10), uint32(0), uint32(0), uint32(0)))
+	sheet = append(sheet, cells...)
+	sheet = append(sheet, biffRec(biffEOF, nil)...)
+	return append(g, sheet...)
+}
+
+func TestParseBIFF(t *testing.T) {
+	// SST: "Фамилия", "Розыск лиц" и строка, разорванная CONTINUE
+	// посередине: первая половина в UTF-16, продолжение сжатое (Latin-1);
+	// у последней строки заголовок в конце записи, а символы в следующей
+	// CONTINUE, которая начинается с флагов (UTF-16, хотя в заголовке сжатые)
+	first := le(uint32(5), uint32(4))
+	first = append(first, sstString("Фамилия")...)
+	first = append(first, sstString("Розыск лиц")...)
+	first = append(first, le(uint16(6), uint8(1))...)
+	first = append(first, utf16LE("ДА")...)
+	cont := append([]byte{0}, "test"...) // флаги: сжатые символы, затем "test"
+	cont = append(cont, le(uint16(2), uint8(0))...)
+	last := append([]byte{0x01}, utf16LE("Да")...)
+	sst := [][]byte{first, cont, last}
+
+	var cells []byte
+	// строка 0: заголовки из SST
+	cells = append(cells, biffRec(biffLabelSST, le(uint16(0), uint16(0), uint16(0), uint32(0)))...)
+	cells = append(cells, biffRec(biffLabelSST, le(uint16(0), uint16(1), uint16(0), uint32(1)))...)
+	// строка 1: MULRK из двух чисел (1985 и 3,5), затем FORMULA со строкой в STRING
+	cells = append(cells, biffRec(biffMulRK, le(uint16(1), uint16(0),
+		uint16(0), uint32(1985<<2|2),
+		uint16(0), uint32(350<<2|3),
+		uint16(1)))...)
+	formula := le(uint16(1), uint16(2), uint16(0), []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, uint16(0), uint32(0))
+	cells = append(cells, biffRec(biffFormula, formula)...)
+	cells = append(cells, biffRec(biffString, append(le(uint16(3), uint8(1)), utf16LE("Нет")...))...)
+	// строка 2: NUMBER, LABEL и ячейки из разорванных строк SST
+	cells = append(cells, biffRec(biffNumber, le(uint16(2), uint16(0), uint16(0), math.Float64bits(5.25)))...)
+	cells = append(cells, biffRec(biffLabel, append(le(uint16(2), uint16(1), uint16(0), uint16(2), uint8(0)), "05"...))...)
+	cells = append(cells, biffRec(biffLabelSST, le(uint16(2), uint16(2), uint16(0), uint32(2)))...)
+	cells = append(cells, biffRec(biffLabelSST, le(uint16(2), uint16(3), uint16(0), uint32(3)))...)
+
+	rows, err := parseBIFF(workbook(sst, cells))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"Фамилия", "Розыск лиц"},
+		{"1985", "3.5", "Нет"},
+		{"5.25", "05", "ДАtest", "Да"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("строк %d, ожидалось %d: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Errorf("строка %d = %q, ожидалось %q", i, rows[i], want[i])
+		}
+	}
+}
+
+// поврежденная длина расширенных данных не должна выделять гигабайты
+func TestParseSSTCorruptedLength(t *testing.T) {
+	data := le(uint32(1), uint32(1), uint16(1), uint8(0x04), uint32(0xFFFFFFFF), uint8('A'))
+	if _, err := parseSST([][]byte{data}); !errors.Is(err, errSSTShort) {
+		t.Errorf("parseSST() ошибка %v, ожидалась %v", err, errSSTShort)
+	}
+}
+
+// настоящий составной файл .xls с ответом ИБД-Ф
+func TestReadXLSRows(t *testing.T) {
+	rows, err := readXLSRows("testdata/ibdf.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"Фамилия", "Имя", "Отчество", "Год рождения", "Месяц рождения", "День рождения", "Розыск лиц"},
+		{"Иванов", "Иван", "Иванович", "1985", "3", "05", "ДА"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("строк %d, ожидалось %d: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Errorf("строка %d = %q, ожидалось %q", i, rows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+// ===== РАЗБИЕНИЕ НА ЧАСТИ =====
+
+// DefaultChunkSize — строк в одном запросе в ИБД-Ф по умолчанию
+const DefaultChunkSize = 500
+
+// ChunkOptions — как делить строки запроса на части (вкладки)
+type ChunkOptions struct {
+	Size          int  `json:"size"`           // строк в части, ограничение ИБД-Ф в регионе
+	KeepDocuments bool `json:"keep_documents"` // строки одного документа (текущее и прежние ФИО) не разделяются
+}
+
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{Size: DefaultChunkSize}
+}
+
+// Chunks делит строки запроса партии на части не больше opts.Size строк.
+// С KeepDocuments часть заканчивается на границе документа; документ,
+// у которого строк больше opts.Size, попадает в отдельную часть целиком.
+func (b *Batch) Chunks(opts ChunkOptions) [][]string {
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	var chunks [][]string
+	if !opts.KeepDocuments {
+		for start := 0; start < len(b.Lines); start += size {
+			end := min(start+size, len(b.Lines))
+			chunks = append(chunks, b.Lines[start:end])
+		}
+		return chunks
+	}
+
+	var chunk []string
+	for _, doc := range b.Documents {
+		lines := documentLines(doc)
+		if len(chunk) > 0 && len(chunk)+len(lines) > size {
+			chunks = append(chunks, chunk)
+			chunk = nil
+		}
+		chunk = append(chunk, lines...)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ===== КОЛОНКИ ТАБЛИЦЫ ИБД-Ф =====
+
+// ColumnAliases — дополнительные названия заголовков для каждой колонки.
+// Ключ — каноническое название ("Фамилия", "Розыск лиц", ...).
+type ColumnAliases map[string][]string
+
+// колонка ответа ИБД-Ф и поле XLSRow, в которое она попадает
+type xlsColumn struct {
+	Header   string
+	Required bool
+	field    func(*XLSRow) *string
+}
+
+var xlsColumns = []xlsColumn{
+	{"Фамилия", true, func(r *XLSRow) *string { return &r.Surname }},
+	{"Имя", true, func(r *XLSRow) *string { return &r.Name }},
+	{"Отчество", true, func(r *XLSRow) *string { return &r.Patronymic }},
+	{"Год рождения", true, func(r *XLSRow) *string { return &r.BirthYear }},
+	{"Месяц рождения", true, func(r *XLSRow) *string { return &r.BirthMonth }},
+	{"День рождения", true, func(r *XLSRow) *string { return &r.BirthDay }},
+	{"Результат", false, func(r *XLSRow) *string { return &r.Result }},
+	{"Розыск лиц", false, func(r *XLSRow) *string { return &r.WantedPersons }},
+	{"ОСК регион", false, func(r *XLSRow) *string { return &r.OSKRegion }},
+	{"ОСК ГИАЦ", false, func(r *XLSRow) *string { return &r.OSKGIAZ }},
+	{"Адмпрактика регион", false, func(r *XLSRow) *string { return &r.AdminPracticeR }},
+	{"Адмпрактика ФИС-М", false, func(r *XLSRow) *string { return &r.AdminPracticeF }},
+	{"ЗАГС рег.смерти", false, func(r *XLSRow) *string { return &r.ZAGSDeath }},
+	{"Запретники", false, func(r *XLSRow) *string { return &r.Restricted }},
+	{"Паспорт РФ", false, func(r *XLSRow) *string { return &r.PassportRF }},
+	{"Реж.высылки", false, func(r *XLSRow) *string { return &r.DeportationMode }},
+}
+
+// DefaultColumnAliases — известные варианты заголовков ИБД-Ф
+func DefaultColumnAliases() ColumnAliases {
+	return ColumnAliases{
+		"Год рождения":       {"Год", "Г.р."},
+		"Месяц рождения":     {"Месяц"},
+		"День рождения":      {"День"},
+		"Розыск лиц":         {"Розыск"},
+		"ОСК регион":         {"ОСК (регион)", "ОСК рег."},
+		"ОСК ГИАЦ":           {"ОСК (ГИАЦ)", "ГИАЦ"},
+		"Адмпрактика регион": {"Адм. практика регион", "Адмпрактика (регион)"},
+		"Адмпрактика ФИС-М":  {"Адм. практика ФИС-М", "Адмпрактика (ФИС-М)", "ФИС-М"},
+		"ЗАГС рег.смерти":    {"ЗАГС регистрация смерти", "ЗАГС"},
+		"Реж.высылки":        {"Режим высылки"},
+	}
+}
+
+// validate проверяет, что ключи — канонические названия колонок
+func (aliases ColumnAliases) validate() error {
+	for _, header := range slices.Sorted(maps.Keys(aliases)) {
+		if _, ok := findXLSColumn(header); !ok {
+			return fmt.Errorf("названия колонок: неизвестная колонка %q", header)
+		}
+	}
+	return nil
+}
+
+// строк в начале таблицы, среди которых ищем заголовок
+const headerSearchRows = 10
+
+// приводим заголовок к виду для сравнения: регистр, ё, пробелы
+func normalizeHeader(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, "ё", "е"))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// columnMapping сопоставляет колонки таблицы полям XLSRow по тексту заголовка
+type columnMapping struct {
+	headerRow int
+	fields    map[int]func(*XLSRow) *string // индекс колонки -> поле
+}
+
+// findColumns ищет строку заголовка и раскладывает колонки по полям.
+// Если обязательных колонок нет, возвращает ошибку со списком.
+func findColumns(rows [][]string, aliases ColumnAliases) (*columnMapping, error) {
+	lookup := make(map[string]int) // нормализованный заголовок -> индекс в xlsColumns
+	for i, col := range xlsColumns {
+		lookup[normalizeHeader(col.Header)] = i
+		for _, alias := range aliases[col.Header] {
+			lookup[normalizeHeader(alias)] = i
+		}
+	}
+
+	var best *columnMapping
+	var bestMissing []string
+
+	for r := 0; r < len(rows) && r < headerSearchRows; r++ {
+		found := make(map[int]int) // индекс в xlsColumns -> индекс колонки
+		for c, cell := range rows[r] {
+			idx, ok := lookup[normalizeHeader(cell)]
+			if !ok {
+				continue
+			}
+			if _, dup := found[idx]; !dup {
+				found[idx] = c
+			}
+		}
+
+		var missing []string
+		for i, col := range xlsColumns {
+			if _, ok := found[i]; !ok && col.Required {
+				missing = append(missing, col.Header)
+			}
+		}
+
+		if best == nil || len(missing) < len(bestMissing) {
+			best = &columnMapping{headerRow: r, fields: make(map[int]func(*XLSRow) *string)}
+			for i, c := range found {
+				best.fields[c] = xlsColumns[i].field
+			}
+			bestMissing = missing
+		}
+		if len(missing) == 0 {
+			break
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("таблица пуста")
+	}
+	if len(bestMissing) > 0 {
+		return nil, fmt.Errorf("не найдены обязательные колонки: %s", strings.Join(bestMissing, ", "))
+	}
+	return best, nil
+}
+
+func findXLSColumn(header string) (xlsColumn, bool) {
+	for _, col := range xlsColumns {
+		if col.Header == header {
+			return col, true
+		}
+	}
+	return xlsColumn{}, false
+}
+
+// Value возвращает значение колонки по ее каноническому названию
+func (r XLSRow) Value(header string) string {
+	col, ok := findXLSColumn(header)
+	if !ok {
+		return ""
+	}
+	return *col.field(&r)
+}
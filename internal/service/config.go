@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ===== НАСТРОЙКИ =====
+
+// Config — настройки сервиса, читаются из JSON файла.
+// Незаданные в файле поля берутся из DefaultConfig.
+type Config struct {
+	// дополнительные названия заголовков колонок ИБД-Ф
+	Columns ColumnAliases `json:"columns"`
+
+	// какие колонки и значения считаются положительным результатом
+	PositiveRules PositiveRules `json:"positive_rules"`
+
+	// правила нормализации ФИО перед сравнением
+	Normalize NormalizeOptions `json:"normalize"`
+
+	// шаблон имени отчета и папка для отчетов (пусто — рядом с файлом ИБД-Ф)
+	ReportName string `json:"report_name"`
+	ReportDir  string `json:"report_dir"`
+
+	// уровень журнала, маскировка персональных данных, ротация файла
+	Log LogOptions `json:"log"`
+
+	// размер частей (вкладок) со строками запроса в ИБД-Ф
+	Chunk ChunkOptions `json:"chunk"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Columns:       DefaultColumnAliases(),
+		PositiveRules: DefaultPositiveRules(),
+		Normalize:     DefaultNormalizeOptions(),
+		ReportName:    DefaultNameTemplate,
+		Log:           DefaultLogOptions(),
+		Chunk:         DefaultChunkOptions(),
+	}
+}
+
+// LoadConfig читает настройки из файла поверх значений по умолчанию
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+
+	// незаданные флаги нормализации, журнала и частей остаются по умолчанию
+	file := Config{Normalize: cfg.Normalize, Log: cfg.Log, Chunk: cfg.Chunk}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	cfg.Normalize = file.Normalize
+	cfg.Log = file.Log
+	if file.Chunk.Size > 0 {
+		cfg.Chunk = file.Chunk
+	}
+	if file.ReportName != "" {
+		cfg.ReportName = file.ReportName
+	}
+	cfg.ReportDir = file.ReportDir
+
+	if err := file.Columns.validate(); err != nil {
+		return nil, err
+	}
+	for header, aliases := range file.Columns {
+		cfg.Columns[header] = append(cfg.Columns[header], aliases...)
+	}
+
+	// правила из файла добавляются к стандартным, правило для той же
+	// колонки заменяет стандартное (пустой values отключает проверку)
+	if file.PositiveRules != nil {
+		if err := file.PositiveRules.validate(); err != nil {
+			return nil, err
+		}
+		cfg.PositiveRules = cfg.PositiveRules.merge(file.PositiveRules)
+	}
+	return cfg, nil
+}
+
+// UserConfigPath — путь к файлу настроек в профиле пользователя
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "GOsuslugiXML", "config.json"), nil
+}
+
+// LoadUserConfig читает настройки из профиля пользователя.
+// Если файла нет, возвращает настройки по умолчанию.
+func LoadUserConfig() (*Config, error) {
+	path, err := UserConfigPath()
+	if err != nil {
+		return DefaultConfig(), nil
+	}
+
+	cfg, err := LoadConfig(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultConfig(), nil
+	}
+	return cfg, err
+}
+
+// SaveUserChunkOptions сохраняет размер частей в файл настроек профиля,
+// остальные настройки в файле не меняются
+func SaveUserChunkOptions(opts ChunkOptions) error {
+	path, err := UserConfigPath()
+	if err != nil {
+		return err
+	}
+	return updateConfigFile(path, "chunk", opts)
+}
+
+// updateConfigFile заменяет в файле настроек одно поле верхнего уровня,
+// файла нет — создает его
+func updateConfigFile(path, key string, value any) error {
+	fields := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fields[key] = raw
+
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
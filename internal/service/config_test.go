@@ -0,0 +1,86 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// правила из файла дополняют стандартные, а не заменяют их
+func TestLoadConfigPositiveRulesMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"positive_rules": [
+		{"column": "Розыск лиц", "values": ["ДА", "да"]},
+		{"column": "ОСК ГИАЦ", "values": []},
+		{"column": "Запретники", "values": ["ДА", "+"]}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		column string
+		values []string
+	}{
+		{"Розыск лиц", []string{"ДА", "да"}},
+		{"ОСК регион", []string{"ДА"}},
+		{"ОСК ГИАЦ", []string{}},
+		{"Запретники", []string{"ДА", "+"}},
+	}
+	for _, tt := range tests {
+		rule, ok := cfg.PositiveRules.ForColumn(tt.column)
+		if !ok {
+			t.Errorf("нет правила для %q", tt.column)
+			continue
+		}
+		if !slices.Equal(rule.Values, tt.values) {
+			t.Errorf("%q: values = %q, ожидалось %q", tt.column, rule.Values, tt.values)
+		}
+	}
+	if len(cfg.PositiveRules) != len(tests) {
+		t.Errorf("правил %d, ожидалось %d", len(cfg.PositiveRules), len(tests))
+	}
+}
+
+// названия колонок дополняют стандартные, опечатка в ключе — ошибка
+func TestLoadConfigColumns(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		data string
+		err  string // "" — без ошибки
+	}{
+		{"известная колонка", `{"columns": {"Розыск лиц": ["Розыск (ИЦ)"]}}`, ""},
+		{"неизвестная колонка", `{"columns": {"Розыск лиц": ["Розыск (ИЦ)"], "Розыск": ["Р"]}}`, `"Розыск"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConfig(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("ошибка %v, ожидалась с %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append(DefaultColumnAliases()["Розыск лиц"], "Розыск (ИЦ)")
+			if got := cfg.Columns["Розыск лиц"]; !slices.Equal(got, want) {
+				t.Errorf("названия %q, ожидалось %q", got, want)
+			}
+		})
+	}
+}
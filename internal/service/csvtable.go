@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ===== CSV / TSV =====
+
+// возможные разделители, при равенстве выигрывает первый
+var csvDelimiters = []rune{';', '\t', ',', '|'}
+
+// строк в начале файла, по которым угадываем разделитель
+const csvSniffLines = 20
+
+// readCSVTable читает ответ ИБД-Ф, сохраненный как CSV или TSV.
+// Кодировка: BOM, иначе UTF-8 если текст корректен, иначе windows-1251.
+func readCSVTable(filename string) ([][]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := decodeCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// строка-подсказка Excel "sep=;" задает разделитель явно
+	var delim rune
+	first, rest, _ := strings.Cut(text, "\n")
+	if sep, ok := strings.CutPrefix(strings.TrimSpace(first), "sep="); ok && sep != "" {
+		delim, _ = utf8.DecodeRuneInString(sep)
+		text = rest
+	} else {
+		delim = detectDelimiter(text)
+	}
+
+	rows, err := parseCSV(text, delim, -1)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("файл CSV пуст")
+	}
+	return rows, nil
+}
+
+// decodeCSV перекодирует содержимое файла в UTF-8
+func decodeCSV(data []byte) (string, error) {
+	r, hasBOM := stripBOM(bytes.NewReader(data))
+	if hasBOM {
+		b, err := io.ReadAll(r)
+		return string(b), err
+	}
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	b, err := charmap.Windows1251.NewDecoder().Bytes(data)
+	return string(b), err
+}
+
+// detectDelimiter выбирает разделитель, дающий больше всего колонок
+// в первых строках файла (по строке заголовка)
+func detectDelimiter(text string) rune {
+	best, bestFields := csvDelimiters[0], 0
+	for _, d := range csvDelimiters {
+		rows, _ := parseCSV(text, d, csvSniffLines)
+		fields := 0
+		for _, row := range rows {
+			fields = max(fields, len(row))
+		}
+		if fields > bestFields {
+			best, bestFields = d, fields
+		}
+	}
+	return best
+}
+
+// parseCSV разбирает текст с разделителем delim, limit < 0 — без ограничения строк
+func parseCSV(text string, delim rune, limit int) ([][]string, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var rows [][]string
+	for limit < 0 || len(rows) < limit {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
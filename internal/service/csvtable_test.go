@@ -0,0 +1,104 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestReadCSVTable(t *testing.T) {
+	cp1251, err := charmap.Windows1251.NewEncoder().String("Фамилия;Имя;Розыск лиц\r\nЁлкин;Пётр;ДА\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{"точка с запятой", "Фамилия;Имя;Розыск лиц\nИванов;Иван;ДА\n",
+			[][]string{{"Фамилия", "Имя", "Розыск лиц"}, {"Иванов", "Иван", "ДА"}}},
+		{"табуляция", "Фамилия\tИмя\tРозыск лиц\nИванов\tИван\t\n",
+			[][]string{{"Фамилия", "Имя", "Розыск лиц"}, {"Иванов", "Иван", ""}}},
+		// без sep= выиграла бы точка с запятой: в строках ее больше
+		{"подсказка sep=,", "sep=,\nФамилия,Имя;Отчество;Год\n\"Иванов\",Иван;Иванович;1985\n",
+			[][]string{{"Фамилия", "Имя;Отчество;Год"}, {"Иванов", "Иван;Иванович;1985"}}},
+		{"BOM UTF-8", "\xEF\xBB\xBFФамилия;Имя\nИванов;Иван\n",
+			[][]string{{"Фамилия", "Имя"}, {"Иванов", "Иван"}}},
+		{"windows-1251", cp1251,
+			[][]string{{"Фамилия", "Имя", "Розыск лиц"}, {"Ёлкин", "Пётр", "ДА"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ответ.csv")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := readCSVTable(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("строк %d, ожидалось %d: %q", len(rows), len(tt.want), rows)
+			}
+			for i := range tt.want {
+				if !slices.Equal(rows[i], tt.want[i]) {
+					t.Errorf("строка %d = %q, ожидалось %q", i, rows[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// формат определяется по содержимому: все файлы называются .xls
+func TestSniffTableFormat(t *testing.T) {
+	xls, err := os.ReadFile("testdata/ibdf.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want tableFormat
+	}{
+		{"составной файл", string(xls), formatXLS},
+		{"zip-архив", "PK\x03\x04\x14\x00\x06\x00", formatXLSX},
+		{"HTML", "\r\n<html><head><meta charset=\"windows-1251\"></head><body><table>", formatHTML},
+		{"HTML с BOM", "\xEF\xBB\xBF<TABLE border=1><TR><TD>Фамилия</TD>", formatHTML},
+		{"HTML после текста", "Ответ ИБД-Ф\n<table><tr><td>Фамилия</td></tr></table>", formatHTML},
+		{"CSV", "Фамилия;Имя;Отчество\nИванов;Иван;Иванович\n", formatCSV},
+		{"TSV", "Фамилия\tИмя\tОтчество\n", formatCSV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ответ.xls")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := sniffTableFormat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("sniffTableFormat() = %s, ожидалось %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("пустой файл", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ответ.xls")
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := sniffTableFormat(path); err == nil {
+			t.Errorf("sniffTableFormat() = %s, ожидалась ошибка", got)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ===== ДАТЫ РОЖДЕНИЯ =====
+
+// Date — дата рождения, неизвестные части равны 0 (например, 00.00.1985)
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// начало отсчета дат Excel (с учетом ошибки 1900 года в Excel)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// ParseDate разбирает дату в одном из форматов:
+// dd.mm.yyyy (в т.ч. 5.3.1985 и 00.00.1985), yyyy-mm-dd, yyyy (только год)
+// и порядковый номер дня Excel (31111).
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	// время после даты не нужно: "1985-03-05T00:00:00", "05.03.1985 0:00:00"
+	if i := strings.IndexAny(s, "T "); i > 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return Date{}, fmt.Errorf("пустая дата")
+	}
+
+	switch {
+	case strings.Contains(s, "."):
+		parts := strings.Split(s, ".")
+		if len(parts) != 3 {
+			break
+		}
+		return newDate(parts[2], parts[1], parts[0], s)
+
+	case strings.Contains(s, "-"):
+		parts := strings.Split(s, "-")
+		if len(parts) != 3 {
+			break
+		}
+		return newDate(parts[0], parts[1], parts[2], s)
+
+	case isDigits(s) && len(s) == 4:
+		return newDate(s, "", "", s)
+
+	case isDigits(s):
+		serial, _ := strconv.Atoi(s)
+		if serial <= 0 {
+			break
+		}
+		t := excelEpoch.AddDate(0, 0, serial)
+		return Date{Year: t.Year(), Month: int(t.Month()), Day: t.Day()}, nil
+	}
+
+	return Date{}, fmt.Errorf("неизвестный формат даты %q", s)
+}
+
+// ParseDateParts собирает дату из отдельных колонок год/месяц/день.
+// Если заполнен только год, он разбирается как полная дата (ISO, номер дня Excel).
+func ParseDateParts(year, month, day string) (Date, error) {
+	year, month, day = strings.TrimSpace(year), strings.TrimSpace(month), strings.TrimSpace(day)
+	if month == "" && day == "" {
+		return ParseDate(year)
+	}
+	return newDate(year, month, day, year+"."+month+"."+day)
+}
+
+func newDate(year, month, day, src string) (Date, error) {
+	var d Date
+	var err error
+
+	// год только из 4 цифр: "85" может быть и 1985, и 2085
+	if d.Year, err = datePart(year); err != nil || d.Year < 1000 || len(strings.TrimSpace(year)) != 4 {
+		return Date{}, fmt.Errorf("неверный год в дате %q, нужен год из 4 цифр", src)
+	}
+	if d.Month, err = datePart(month); err != nil || d.Month > 12 {
+		return Date{}, fmt.Errorf("неверный месяц в дате %q", src)
+	}
+	if d.Day, err = datePart(day); err != nil || d.Day > 31 {
+		return Date{}, fmt.Errorf("неверный день в дате %q", src)
+	}
+	return d, nil
+}
+
+// пустая часть даты — неизвестна (0)
+func datePart(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if !isDigits(s) {
+		return 0, fmt.Errorf("не число: %q", s)
+	}
+	return strconv.Atoi(s)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Line — дата для строки запроса в ИБД-Ф: yyyy;mm;dd, неизвестные части 00
+func (d Date) Line() string {
+	return fmt.Sprintf("%04d;%02d;%02d", d.Year, d.Month, d.Day)
+}
+
+// Key — дата для ключа сравнения: yyyy_mm_dd, неизвестные части 00
+func (d Date) Key() string {
+	return fmt.Sprintf("%04d_%02d_%02d", d.Year, d.Month, d.Day)
+}
+
+// String — дата в виде dd.mm.yyyy
+func (d Date) String() string {
+	return fmt.Sprintf("%02d.%02d.%04d", d.Day, d.Month, d.Year)
+}
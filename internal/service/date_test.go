@@ -0,0 +1,69 @@
+package service
+
+import "testing"
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		line string // "" — ожидается ошибка
+	}{
+		{"05.03.1985", "1985;03;05"},
+		{"5.3.1985", "1985;03;05"},
+		{"00.00.1960", "1960;00;00"},
+		{"1985-03-05", "1985;03;05"},
+		{"1985-03-05T00:00:00", "1985;03;05"},
+		{"05.03.1985 0:00:00", "1985;03;05"},
+		{"1960", "1960;00;00"},
+		{"31111", "1985;03;05"},
+		{"05.03.85", ""},
+		{"05.03.0085", ""},
+		{"85-03-05", ""},
+		{"32.01.1985", ""},
+		{"05.13.1985", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d, err := ParseDate(tt.in)
+			if tt.line == "" {
+				if err == nil {
+					t.Errorf("ParseDate(%q) = %v, ожидалась ошибка", tt.in, d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q): %v", tt.in, err)
+			}
+			if got := d.Line(); got != tt.line {
+				t.Errorf("ParseDate(%q).Line() = %q, ожидалось %q", tt.in, got, tt.line)
+			}
+		})
+	}
+}
+
+func TestParseDateParts(t *testing.T) {
+	tests := []struct {
+		year, month, day string
+		key              string // "" — ожидается ошибка
+	}{
+		{"1985", "3", "05", "1985_03_05"},
+		{"1985", "", "", "1985_00_00"},
+		{"85", "03", "05", ""},
+	}
+	for _, tt := range tests {
+		d, err := ParseDateParts(tt.year, tt.month, tt.day)
+		if tt.key == "" {
+			if err == nil {
+				t.Errorf("ParseDateParts(%q, %q, %q) = %v, ожидалась ошибка", tt.year, tt.month, tt.day, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDateParts(%q, %q, %q): %v", tt.year, tt.month, tt.day, err)
+			continue
+		}
+		if got := d.Key(); got != tt.key {
+			t.Errorf("ParseDateParts(%q, %q, %q).Key() = %q, ожидалось %q", tt.year, tt.month, tt.day, got, tt.key)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// ===== ПОТОКОВЫЙ РАЗБОР XML =====
+
+// DecodeDocuments читает выгрузку по токенам и передает fn каждый <Document>
+// из <List> по одному, не загружая весь файл в память. Кодировка берется
+// из BOM или из объявления XML.
+// Ошибка из fn прерывает разбор и возвращается как есть.
+func DecodeDocuments(r io.Reader, fn func(Document) error) error {
+	return DecodeDocumentsContext(context.Background(), r, fn)
+}
+
+// DecodeDocumentsContext — DecodeDocuments, который перед каждым документом
+// проверяет отмену ctx и возвращает ctx.Err()
+func DecodeDocumentsContext(ctx context.Context, r io.Reader, fn func(Document) error) error {
+	dec := newXMLDecoder(r)
+
+	root := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if !root {
+				return fmt.Errorf("элемент <List> не найден в XML")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !root {
+				if t.Name.Local != "List" {
+					return fmt.Errorf("ожидался элемент <List>, получен <%s>", t.Name.Local)
+				}
+				root = true
+				continue
+			}
+
+			if t.Name.Local != "Document" {
+				// чужие элементы внутри List пропускаем целиком
+				if err := dec.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var doc Document
+			if err := dec.DecodeElement(&doc, &t); err != nil {
+				return err
+			}
+			if err := fn(doc); err != nil {
+				return err
+			}
+
+		case xml.EndElement:
+			// закрылся List — дальше читать нечего
+			if root && t.Name.Local == "List" {
+				return nil
+			}
+		}
+	}
+}
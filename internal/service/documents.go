@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ===== ИТОГ ПО ДОКУМЕНТАМ =====
+
+// итог по заявителю — то, что отвечаем на Госуслуги
+const (
+	VerdictPositive   = "Положительный"
+	VerdictNegative   = "Отрицательный"
+	VerdictIncomplete = "Неполный ответ" // отрицательный, но не на все ФИО есть ответ
+	VerdictNoAnswer   = "Нет ответа ИБД-Ф"
+	VerdictReview     = "Требует проверки" // строки ответа подходят и к другим документам
+)
+
+const documentSheet = "По документам"
+
+// колонки проверок ИБД-Ф: все необязательные колонки ответа
+func checkHeaders() []string {
+	var headers []string
+	for _, col := range xlsColumns {
+		if !col.Required {
+			headers = append(headers, col.Header)
+		}
+	}
+	return headers
+}
+
+func documentHeaders() []string {
+	headers := []string{"№ документа", "ФИО", "Строк ИБД-Ф"}
+	headers = append(headers, checkHeaders()...)
+	return append(headers, "Положительно по ФИО", "Итог")
+}
+
+// documentRow — строка листа «По документам» и положительные ячейки
+type documentRow struct {
+	values   []string // в порядке documentHeaders
+	positive []bool
+	verdict  string
+}
+
+// documentRows объединяет строки ИБД-Ф каждого документа: значение проверки
+// положительное, если оно положительное хотя бы по одному ФИО (ИЛИ).
+// Неоднозначные строки (однофамильцы) в объединение не входят: без
+// положительного результата по своим строкам документ требует проверки.
+func documentRows(res *MatchResult, rules PositiveRules) []documentRow {
+	checks := checkHeaders()
+	out := make([]documentRow, 0, len(res.Documents))
+
+	for _, doc := range res.Documents {
+		rows := make([]XLSRow, len(doc.Rows))
+		for i, idx := range doc.Rows {
+			rows[i] = res.Rows[idx]
+		}
+
+		count := strconv.Itoa(len(rows))
+		if len(doc.Ambiguous) > 0 {
+			count += fmt.Sprintf(" (+%d неоднозначных)", len(doc.Ambiguous))
+		}
+
+		r := documentRow{
+			values:   []string{doc.DocNumber, doc.FIO, count},
+			positive: make([]bool, 3, len(checks)+5),
+		}
+
+		for _, header := range checks {
+			value, positive := mergeCheck(rows, header, rules)
+			r.values = append(r.values, value)
+			r.positive = append(r.positive, positive)
+		}
+
+		hits := positiveAliases(rows, doc.name, res.norm, rules)
+		switch {
+		case len(hits) > 0:
+			r.verdict = VerdictPositive
+		case len(doc.Ambiguous) > 0:
+			r.verdict = VerdictReview
+		case len(rows) == 0:
+			r.verdict = VerdictNoAnswer
+		case len(doc.MissingLines) > 0:
+			r.verdict = VerdictIncomplete
+		default:
+			r.verdict = VerdictNegative
+		}
+
+		r.values = append(r.values, strings.Join(hits, "; "), r.verdict)
+		r.positive = append(r.positive, false, r.verdict == VerdictPositive)
+		out = append(out, r)
+	}
+	return out
+}
+
+// mergeCheck — значение проверки по всем ФИО документа: первое положительное,
+// иначе все различные значения через " / "
+func mergeCheck(rows []XLSRow, header string, rules PositiveRules) (string, bool) {
+	rule, hasRule := rules.ForColumn(header)
+
+	var values []string
+	for _, row := range rows {
+		value := strings.TrimSpace(row.Value(header))
+		if hasRule && rule.Match(value) {
+			return value, true
+		}
+		if value != "" && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, " / "), false
+}
+
+// positiveAliases — по каким ФИО и проверкам получен положительный результат,
+// например "Петров Иван Иванович (прежнее ФИО): Розыск лиц".
+// Текущее ФИО от прежнего отличается по тем же правилам, что и при сравнении.
+func positiveAliases(rows []XLSRow, current FIO, norm NormalizeOptions, rules PositiveRules) []string {
+	current = norm.FIO(current)
+	var hits []string
+	for _, row := range rows {
+		var columns []string
+		for _, rule := range rules {
+			if rule.Match(row.Value(rule.Column)) && !slices.Contains(columns, rule.Column) {
+				columns = append(columns, rule.Column)
+			}
+		}
+		if len(columns) == 0 {
+			continue
+		}
+
+		name := FIO{Surname: row.Surname, Name: row.Name, Patronymic: row.Patronymic}
+		fio := strings.Join(strings.Fields(row.Surname+" "+row.Name+" "+row.Patronymic), " ")
+		if norm.FIO(name) != current {
+			fio += " (прежнее ФИО)"
+		}
+		hit := fio + ": " + strings.Join(columns, ", ")
+		if !slices.Contains(hits, hit) {
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
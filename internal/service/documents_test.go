@@ -0,0 +1,71 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+const documentsXML = `<List>
+ <Document>
+  <DocumentID>1</DocumentID>
+  <RequestInfo><ConvictionPerson>
+   <CPSurname>Семёнов</CPSurname><CPName>Иван</CPName><CPPatronymic>Петрович</CPPatronymic>
+   <CPBirthday>05.03.1985</CPBirthday>
+  </ConvictionPerson></RequestInfo>
+ </Document>
+ <Document>
+  <DocumentID>2</DocumentID>
+  <RequestInfo><ConvictionPerson>
+   <CPSurname>Кузнецов</CPSurname><CPName>Олег</CPName><CPPatronymic>Андреевич</CPPatronymic>
+   <CPBirthday>12.11.1990</CPBirthday>
+  </ConvictionPerson></RequestInfo>
+ </Document>
+ <Document>
+  <DocumentID>3</DocumentID>
+  <RequestInfo><ConvictionPerson>
+   <CPSurname>Кузнецов</CPSurname><CPName>Олег</CPName><CPPatronymic>Андреевич</CPPatronymic>
+   <CPBirthday>12.11.1990</CPBirthday>
+  </ConvictionPerson></RequestInfo>
+ </Document>
+</List>`
+
+func compareDocuments(t *testing.T, rows []XLSRow) []documentRow {
+	t.Helper()
+	batch, err := NewXMLParser().ReadBatch(strings.NewReader(documentsXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return documentRows(batch.Compare(rows, nil), DefaultPositiveRules())
+}
+
+// однофамильцы: положительная строка не дает положительный итог обоим документам
+func TestDocumentRowsAmbiguous(t *testing.T) {
+	docs := compareDocuments(t, []XLSRow{{
+		Surname: "Кузнецов", Name: "Олег", Patronymic: "Андреевич",
+		BirthYear: "1990", BirthMonth: "11", BirthDay: "12",
+		WantedPersons: "ДА",
+	}})
+
+	for _, doc := range docs[1:] {
+		if doc.verdict != VerdictReview {
+			t.Errorf("документ %s: итог %q, ожидался %q", doc.values[0], doc.verdict, VerdictReview)
+		}
+	}
+}
+
+// текущее ФИО сравнивается с учетом нормализации: регистр и ё не делают его прежним
+func TestDocumentRowsCurrentName(t *testing.T) {
+	docs := compareDocuments(t, []XLSRow{{
+		Surname: "СЕМЕНОВ", Name: "иван", Patronymic: "Петрович",
+		BirthYear: "1985", BirthMonth: "03", BirthDay: "05",
+		WantedPersons: "ДА",
+	}})
+
+	doc := docs[0]
+	if doc.verdict != VerdictPositive {
+		t.Fatalf("итог %q, ожидался %q", doc.verdict, VerdictPositive)
+	}
+	if hits := doc.values[len(doc.values)-2]; strings.Contains(hits, "прежнее ФИО") {
+		t.Errorf("текущее ФИО помечено как прежнее: %q", hits)
+	}
+}
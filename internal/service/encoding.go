@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// ===== КОДИРОВКИ =====
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+)
+
+// newXMLDecoder создает xml.Decoder, который понимает BOM и кодировку
+// из объявления <?xml ... encoding="..."?> (windows-1251, KOI8-R и т.д.)
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	r, hasBOM := stripBOM(r)
+
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		// по BOM текст уже перекодирован в UTF-8, объявление не важно
+		if hasBOM {
+			return input, nil
+		}
+		return charsetReader(label, input)
+	}
+	return dec
+}
+
+// stripBOM убирает BOM и перекодирует UTF-16 в UTF-8.
+// Второе значение сообщает, был ли найден BOM.
+func stripBOM(r io.Reader) (io.Reader, bool) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(3)
+
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		br.Discard(len(bomUTF8))
+		return br, true
+	case bytes.HasPrefix(head, bomUTF16BE):
+		dec := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()
+		return transform.NewReader(br, dec), true
+	case bytes.HasPrefix(head, bomUTF16LE):
+		dec := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()
+		return transform.NewReader(br, dec), true
+	}
+	return br, false
+}
+
+// charsetReader возвращает reader, перекодирующий input из label в UTF-8
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("неподдерживаемая кодировка %q", label)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"io"
+	"slices"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ===== EXCEL =====
+
+type excelReportWriter struct{}
+
+func (excelReportWriter) Extension() string { return ".xlsx" }
+
+func (excelReportWriter) Write(ctx context.Context, w io.Writer, res *MatchResult, opts ReportOptions) ([]SheetRows, error) {
+	f, err := buildExcelFile(ctx, res, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := f.Write(w); err != nil {
+		return nil, err
+	}
+
+	// листы в том порядке, в каком их создает buildExcelFile
+	return []SheetRows{
+		{mainSheet, len(res.Rows)},
+		{positiveSheet, positiveCount(res.Rows, opts.rules())},
+		{documentSheet, len(res.Documents)},
+		{reviewSheet, len(reviewRows(res.Rows))},
+		{orphanSheet, len(orphanRows(res.Rows, opts))},
+		{missingSheet, len(missingRows(res.Missing))},
+	}, nil
+}
+
+// заголовки дополнительных колонок из XML
+var detailHeaders = []string{
+	"Место рождения", "Адрес регистрации", "Документ",
+	"Дата заявления", "Цель получения справки",
+}
+
+func detailValues(d *DocumentDetails) []string {
+	if d == nil {
+		return make([]string, len(detailHeaders))
+	}
+	return []string{d.BirthPlace, d.RegAddress, d.IdentityDoc, d.RequestDate, d.RequestPurpose}
+}
+
+func buildExcelFile(ctx context.Context, res *MatchResult, opts ReportOptions) (*excelize.File, error) {
+	xlsRows := res.Rows
+	f := excelize.NewFile()
+	f.NewSheet(positiveSheet)
+
+	// ===== Стили =====
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+
+	gridStyle, _ := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+
+	highlightStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFFF00"}}, // желтый
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+
+	// Стиль для жирной темной ячейки с положительным значением
+	darkDaCellStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FF6600"}}, // темно-оранжевый
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+
+	// Стиль для пометки в колонке «Статус»
+	statusStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}}, // светло-красный
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+
+	headers := reportHeaders(opts)
+
+	for i, header := range headers {
+		cellMain, _ := excelize.CoordinatesToCellName(i+1, 1)
+		cellPos, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(mainSheet, cellMain, header)
+		f.SetCellValue(positiveSheet, cellPos, header)
+		f.SetCellStyle(mainSheet, cellMain, cellMain, headerStyle)
+		f.SetCellStyle(positiveSheet, cellPos, cellPos, headerStyle)
+	}
+
+	mainRowIndex := 2
+	posRowIndex := 2
+
+	rules := opts.rules()
+
+	// правило для каждой колонки отчета (индекс с 0)
+	colRules := make(map[int]PositiveRule)
+	for j, header := range headers {
+		if rule, ok := rules.ForColumn(header); ok {
+			colRules[j] = rule
+		}
+	}
+	isPositiveCell := func(j int, value string) bool {
+		rule, ok := colRules[j]
+		return ok && rule.Match(value)
+	}
+
+	statusCol := slices.Index(headers, "Статус")
+
+	for i, row := range xlsRows {
+		if err := progressStep(ctx, StageWrite, i+1, len(xlsRows)); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		isPositive := rules.IsPositive(row)
+
+		rowData := reportRowData(row, opts)
+
+		// --- Основной лист ---
+		// Жёлтая подсветка всей строки на основном листе
+		rowStyle := gridStyle
+		if isPositive {
+			rowStyle = highlightStyle
+		}
+		for j, value := range rowData {
+			cell, _ := excelize.CoordinatesToCellName(j+1, mainRowIndex)
+			f.SetCellValue(mainSheet, cell, value)
+			f.SetCellStyle(mainSheet, cell, cell, rowStyle)
+
+			// Положительное значение делаем темным и жирным
+			if isPositiveCell(j, value) {
+				f.SetCellStyle(mainSheet, cell, cell, darkDaCellStyle)
+			}
+			if j == statusCol && value != StatusMatched {
+				f.SetCellStyle(mainSheet, cell, cell, statusStyle)
+			}
+		}
+
+		// --- Положительный результат ---
+		if isPositive {
+			for j, value := range rowData {
+				cell, _ := excelize.CoordinatesToCellName(j+1, posRowIndex)
+				f.SetCellValue(positiveSheet, cell, value)
+				f.SetCellStyle(positiveSheet, cell, cell, gridStyle)
+				if isPositiveCell(j, value) {
+					f.SetCellStyle(positiveSheet, cell, cell, darkDaCellStyle)
+				}
+			}
+			posRowIndex++
+		}
+
+		mainRowIndex++
+	}
+
+	// Ширина колонок
+	for i := 1; i <= len(headers); i++ {
+		col, _ := excelize.ColumnNumberToName(i)
+		f.SetColWidth(mainSheet, col, col, 15)
+		f.SetColWidth(positiveSheet, col, col, 15)
+	}
+
+	// --- По документам: итог по заявителю по всем его ФИО ---
+	writeDocumentSheet(f, documentRows(res, rules), headerStyle, gridStyle, highlightStyle, darkDaCellStyle)
+
+	// --- Требует проверки: кандидаты нечеткого сравнения ---
+	writeSimpleSheet(f, reviewSheet, reviewHeaders, reviewRows(xlsRows), headerStyle, gridStyle)
+
+	// --- Нет в XML: строки ИБД-Ф без документа (чужая партия) ---
+	writeSimpleSheet(f, orphanSheet, headers, orphanRows(xlsRows, opts), headerStyle, gridStyle)
+
+	// --- Нет ответа ИБД-Ф: строки запроса для повторной отправки ---
+	writeSimpleSheet(f, missingSheet, missingHeaders, missingRows(res.Missing), headerStyle, gridStyle)
+
+	return f, nil
+}
+
+// листы Excel отчета
+const (
+	mainSheet     = "Sheet1"
+	positiveSheet = "Положительный результат"
+)
+
+const missingSheet = "Нет ответа ИБД-Ф"
+
+var missingHeaders = []string{"№ документа", "ФИО", "Строка запроса"}
+
+func missingRows(missing []MissingDocument) [][]string {
+	var rows [][]string
+	for _, doc := range missing {
+		for _, line := range doc.Lines {
+			rows = append(rows, []string{doc.DocNumber, doc.FIO, line})
+		}
+	}
+	return rows
+}
+
+// заголовки основного листа
+func reportHeaders(opts ReportOptions) []string {
+	headers := []string{
+		"№ документа", "Фамилия", "Имя", "Отчество",
+		"Год рождения", "Месяц рождения", "День рождения",
+		"Результат", "Розыск лиц", "ОСК регион", "ОСК ГИАЦ",
+		"Адмпрактика регион", "Адмпрактика ФИС-М",
+		"ЗАГС рег.смерти", "Запретники", "Паспорт РФ", "Реж.высылки",
+		"Статус",
+	}
+	if opts.ExtraColumns {
+		headers = append(headers, detailHeaders...)
+	}
+	return headers
+}
+
+// значения строки в порядке reportHeaders
+func reportRowData(row XLSRow, opts ReportOptions) []string {
+	rowData := []string{
+		row.DocumentNumber,
+		row.Surname,
+		row.Name,
+		row.Patronymic,
+		row.BirthYear,
+		row.BirthMonth,
+		row.BirthDay,
+		row.Result,
+		row.WantedPersons,
+		row.OSKRegion,
+		row.OSKGIAZ,
+		row.AdminPracticeR,
+		row.AdminPracticeF,
+		row.ZAGSDeath,
+		row.Restricted,
+		row.PassportRF,
+		row.DeportationMode,
+		row.Status(),
+	}
+	if opts.ExtraColumns {
+		rowData = append(rowData, detailValues(row.Details)...)
+	}
+	return rowData
+}
+
+const orphanSheet = "Нет в XML"
+
+func orphanRows(xlsRows []XLSRow, opts ReportOptions) [][]string {
+	var rows [][]string
+	for _, row := range xlsRows {
+		if row.Orphan() {
+			rows = append(rows, reportRowData(row, opts))
+		}
+	}
+	return rows
+}
+
+const reviewSheet = "Требует проверки"
+
+var reviewHeaders = []string{
+	"Фамилия", "Имя", "Отчество",
+	"Год рождения", "Месяц рождения", "День рождения",
+	"№ документа", "ФИО в XML", "Дата рождения в XML", "Уверенность", "Причина",
+}
+
+func reviewRows(xlsRows []XLSRow) [][]string {
+	var rows [][]string
+	for _, row := range xlsRows {
+		for _, c := range row.Candidates {
+			rows = append(rows, []string{
+				row.Surname, row.Name, row.Patronymic,
+				row.BirthYear, row.BirthMonth, row.BirthDay,
+				c.DocNumber, c.FIO, c.Birthday, c.ConfidencePercent(), c.Reason,
+			})
+		}
+	}
+	return rows
+}
+
+// writeDocumentSheet создает лист «По документам»: положительный итог
+// подсвечивается строкой, положительные проверки — как на основном листе
+func writeDocumentSheet(f *excelize.File, rows []documentRow, headerStyle, gridStyle, highlightStyle, positiveStyle int) {
+	f.NewSheet(documentSheet)
+
+	headers := documentHeaders()
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(documentSheet, cell, header)
+		f.SetCellStyle(documentSheet, cell, cell, headerStyle)
+	}
+
+	for r, row := range rows {
+		rowStyle := gridStyle
+		if row.verdict == VerdictPositive {
+			rowStyle = highlightStyle
+		}
+		for j, value := range row.values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, r+2)
+			f.SetCellValue(documentSheet, cell, value)
+			f.SetCellStyle(documentSheet, cell, cell, rowStyle)
+			if row.positive[j] {
+				f.SetCellStyle(documentSheet, cell, cell, positiveStyle)
+			}
+		}
+	}
+
+	for i := 1; i <= len(headers); i++ {
+		col, _ := excelize.ColumnNumberToName(i)
+		f.SetColWidth(documentSheet, col, col, 15)
+	}
+}
+
+// writeSimpleSheet создает лист с заголовком и сеткой без подсветки
+func writeSimpleSheet(f *excelize.File, sheet string, headers []string, rows [][]string, headerStyle, gridStyle int) {
+	f.NewSheet(sheet)
+
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+
+	for r, row := range rows {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, r+2)
+			f.SetCellValue(sheet, cell, value)
+			f.SetCellStyle(sheet, cell, cell, gridStyle)
+		}
+	}
+
+	for i := 1; i <= len(headers); i++ {
+		col, _ := excelize.ColumnNumberToName(i)
+		f.SetColWidth(sheet, col, col, 15)
+	}
+}
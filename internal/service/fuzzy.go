@@ -0,0 +1,213 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ===== НЕЧЕТКОЕ СРАВНЕНИЕ =====
+
+// MatchCandidate — возможный документ для строки без точного совпадения
+type MatchCandidate struct {
+	DocNumber  string
+	FIO        string  // ФИО в XML
+	Birthday   string  // дата рождения в XML
+	Confidence float64 // от 0 до 1
+	Reason     string
+}
+
+const (
+	fuzzyMinConfidence = 0.75 // ниже — не показываем
+	fuzzyMinPart       = 0.5  // минимальное сходство каждой части ФИО
+	fuzzyMaxCandidates = 3    // кандидатов на строку
+	swappedDatePenalty = 0.1  // штраф за переставленные день и месяц
+	translitPenalty    = 0.05 // штраф за совпадение только после транслитерации
+)
+
+// полное ФИО документа, подготовленное для нечеткого сравнения
+type fuzzyEntry struct {
+	doc  int
+	fio  FIO    // как в XML
+	norm FIO    // нормализованное
+	name string // нормализованное "фамилия имя отчество"
+	date Date
+}
+
+func (b *Batch) fuzzyEntries() []fuzzyEntry {
+	var entries []fuzzyEntry
+	for i, doc := range b.Documents {
+		p := doc.RequestInfo.ConvictionPerson
+		date, err := ParseDate(p.CPBirthday)
+		if err != nil {
+			continue
+		}
+		for _, fio := range p.FullNames() {
+			entries = append(entries, fuzzyEntry{
+				doc:  i,
+				fio:  fio,
+				norm: b.norm.FIO(fio),
+				name: fuzzyName(fio, b.norm),
+				date: date,
+			})
+		}
+	}
+	return entries
+}
+
+// fuzzyCandidates ищет похожие документы для строки XLS: расстояние
+// Левенштейна по ФИО, транслитерация латиницы, переставленные день и месяц
+func (b *Batch) fuzzyCandidates(row XLSRow, entries []fuzzyEntry) []MatchCandidate {
+	date, err := ParseDateParts(row.BirthYear, row.BirthMonth, row.BirthDay)
+	if err != nil {
+		return nil
+	}
+
+	rowFIO := FIO{Surname: row.Surname, Name: row.Name, Patronymic: row.Patronymic}
+	rowNorm := b.norm.FIO(rowFIO)
+	translitNorm := b.norm.FIO(transliterateFIO(rowFIO))
+	name := fuzzyName(rowFIO, b.norm)
+	translit := fuzzyName(transliterateFIO(rowFIO), b.norm)
+
+	best := make(map[int]MatchCandidate) // документ -> лучший кандидат
+	for _, e := range entries {
+		var reasons []string
+		penalty := 0.0
+
+		switch {
+		case e.date == date:
+		case e.date.Year == date.Year && e.date.Day == date.Month && e.date.Month == date.Day:
+			penalty = swappedDatePenalty
+			reasons = append(reasons, "день и месяц переставлены")
+		default:
+			continue
+		}
+
+		similarity, dist := 0.0, 0
+		if partsClose(rowNorm, e.norm) {
+			similarity, dist = nameSimilarity(name, e.name)
+		}
+		if translit != name && partsClose(translitNorm, e.norm) {
+			if s, d := nameSimilarity(translit, e.name); s-translitPenalty > similarity {
+				similarity, dist = s-translitPenalty, d
+				reasons = append(reasons, "транслитерация")
+			}
+		}
+		if dist > 0 {
+			reasons = append(reasons, fmt.Sprintf("ФИО отличается на %d симв.", dist))
+		}
+
+		// без расхождений строка совпала бы точно
+		confidence := similarity - penalty
+		if confidence < fuzzyMinConfidence || len(reasons) == 0 {
+			continue
+		}
+
+		if prev, ok := best[e.doc]; ok && prev.Confidence >= confidence {
+			continue
+		}
+		best[e.doc] = MatchCandidate{
+			DocNumber:  b.Documents[e.doc].DocNumber,
+			FIO:        strings.Join([]string{e.fio.Surname, e.fio.Name, e.fio.Patronymic}, " "),
+			Birthday:   e.date.String(),
+			Confidence: confidence,
+			Reason:     strings.Join(reasons, ", "),
+		}
+	}
+
+	candidates := make([]MatchCandidate, 0, len(best))
+	for _, c := range best {
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].DocNumber < candidates[j].DocNumber
+	})
+	if len(candidates) > fuzzyMaxCandidates {
+		candidates = candidates[:fuzzyMaxCandidates]
+	}
+	return candidates
+}
+
+// ConfidencePercent — уверенность в процентах для отчета
+func (c MatchCandidate) ConfidencePercent() string {
+	return fmt.Sprintf("%.0f%%", c.Confidence*100)
+}
+
+func fuzzyName(fio FIO, norm NormalizeOptions) string {
+	fio = norm.FIO(fio)
+	return strings.ToLower(strings.Join([]string{fio.Surname, fio.Name, fio.Patronymic}, " "))
+}
+
+// nameSimilarity — 1 минус доля отличающихся символов и само расстояние
+func nameSimilarity(a, b string) (float64, int) {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1, 0
+	}
+	dist := levenshtein(ra, rb)
+	return 1 - float64(dist)/float64(longest), dist
+}
+
+// partsClose — фамилия, имя и отчество по отдельности не слишком отличаются,
+// чтобы совпадение имени и отчества не перевешивало другую фамилию
+func partsClose(a, b FIO) bool {
+	for _, pair := range [][2]string{
+		{a.Surname, b.Surname}, {a.Name, b.Name}, {a.Patronymic, b.Patronymic},
+	} {
+		if s, _ := nameSimilarity(strings.ToLower(pair[0]), strings.ToLower(pair[1])); s < fuzzyMinPart {
+			return false
+		}
+	}
+	return true
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// ===== ТРАНСЛИТЕРАЦИЯ =====
+
+// латиница -> кириллица, сначала длинные сочетания
+var translitReplacer = strings.NewReplacer(
+	"shch", "щ", "sch", "щ",
+	"zh", "ж", "kh", "х", "ts", "ц", "ch", "ч", "sh", "ш",
+	"yu", "ю", "iu", "ю", "ya", "я", "ia", "я", "yo", "е", "ye", "е",
+	"iy", "ий", "yi", "ый", "y", "ы",
+	"a", "а", "b", "б", "v", "в", "g", "г", "d", "д", "e", "е", "z", "з",
+	"i", "и", "j", "й", "k", "к", "l", "л", "m", "м", "n", "н", "o", "о",
+	"p", "п", "r", "р", "s", "с", "t", "т", "u", "у", "f", "ф", "h", "х",
+	"c", "к", "w", "в", "x", "кс", "q", "к",
+)
+
+func transliterate(s string) string {
+	return translitReplacer.Replace(strings.ToLower(s))
+}
+
+func transliterateFIO(fio FIO) FIO {
+	return FIO{
+		Surname:    transliterate(fio.Surname),
+		Name:       transliterate(fio.Name),
+		Patronymic: transliterate(fio.Patronymic),
+	}
+}
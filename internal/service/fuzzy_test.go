@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func fuzzyXML(people ...string) string {
+	var b strings.Builder
+	b.WriteString("<List>")
+	for i, p := range people {
+		fio, birthday, _ := strings.Cut(p, ";")
+		parts := strings.Fields(fio)
+		fmt.Fprintf(&b, `<Document><DocumentID>%d</DocumentID><RequestInfo><ConvictionPerson>
+<CPSurname>%s</CPSurname><CPName>%s</CPName><CPPatronymic>%s</CPPatronymic>
+<CPBirthday>%s</CPBirthday></ConvictionPerson></RequestInfo></Document>`,
+			i+1, parts[0], parts[1], parts[2], birthday)
+	}
+	b.WriteString("</List>")
+	return b.String()
+}
+
+func TestFuzzyCandidates(t *testing.T) {
+	batch, err := NewXMLParser().ReadBatch(strings.NewReader(fuzzyXML(
+		"Семёнов Иван Петрович;05.03.1985",
+		"Кузнецов Олег Андреевич;12.11.1990",
+		"Жуков Максим Юрьевич;07.08.1979",
+		"Орлов Павел Ильич;01.02.2000",
+		"Орлов Павел Ильич;01.02.2000",
+		"Орлов Павел Ильич;01.02.2000",
+		"Орлов Павел Ильич;01.02.2000",
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		fio    string
+		date   string // год, месяц, день через пробел
+		docs   []string
+		reason string
+	}{
+		{"опечатка в одну букву", "Семенав Иван Петрович", "1985 03 05", []string{"1"}, "ФИО отличается на 1 симв."},
+		{"переставлены день и месяц", "Кузнецов Олег Андреевич", "1990 12 11", []string{"2"}, "день и месяц переставлены"},
+		{"латиница", "Zhukov Maksim Yurevich", "1979 08 07", []string{"3"}, "транслитерация"},
+		// 4 буквы из 21: 81% проходит порог 75%, а с перестановкой даты (-10%) уже нет
+		{"далекое ФИО", "Самонав Иван Петравич", "1985 03 05", []string{"1"}, "ФИО отличается на 4 симв."},
+		{"далекое ФИО и перестановка", "Самонав Иван Петравич", "1985 05 03", nil, ""},
+		// общее сходство ФИО выше порога, но отчество отличается больше чем наполовину
+		{"однофамилец с другим отчеством", "Семенов Иван Сергеевич", "1985 03 05", nil, ""},
+		{"другая дата", "Семенав Иван Петрович", "1985 03 06", nil, ""},
+		{"не больше трех кандидатов", "Орлав Павел Ильич", "2000 02 01", []string{"4", "5", "6"}, "ФИО отличается на 1 симв."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fio := strings.Fields(tt.fio)
+			date := strings.Fields(tt.date)
+			rows := batch.Match([]XLSRow{{
+				Surname: fio[0], Name: fio[1], Patronymic: fio[2],
+				BirthYear: date[0], BirthMonth: date[1], BirthDay: date[2],
+			}})
+
+			candidates := rows[0].Candidates
+			var docs []string
+			for _, c := range candidates {
+				docs = append(docs, c.DocNumber)
+				if c.Confidence < fuzzyMinConfidence || c.Confidence > 1 {
+					t.Errorf("документ %s: уверенность %v вне [%v, 1]", c.DocNumber, c.Confidence, fuzzyMinConfidence)
+				}
+				if !strings.Contains(c.Reason, tt.reason) {
+					t.Errorf("документ %s: причина %q, ожидалось %q", c.DocNumber, c.Reason, tt.reason)
+				}
+			}
+			if !slices.Equal(docs, tt.docs) {
+				t.Errorf("кандидаты %q, ожидались %q", docs, tt.docs)
+			}
+		})
+	}
+}
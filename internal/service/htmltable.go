@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ===== HTML ТАБЛИЦЫ (выгрузка ИБД-Ф в .xls как HTML) =====
+
+// больше не растягиваем ячейку, чтобы битый colspan не съел память
+const maxHTMLSpan = 1000
+
+// ячейка таблицы до раскрытия colspan/rowspan
+type htmlCell struct {
+	text    string
+	colspan int
+	rowspan int
+}
+
+// таблица, которая сейчас разбирается (таблицы бывают вложенными)
+type htmlTable struct {
+	rows  [][]htmlCell
+	row   []htmlCell
+	inRow bool
+	cell  *htmlCell
+	buf   strings.Builder
+}
+
+func (t *htmlTable) startRow() {
+	t.endRow()
+	t.inRow = true
+}
+
+func (t *htmlTable) endRow() {
+	t.endCell()
+	if t.inRow && len(t.row) > 0 {
+		t.rows = append(t.rows, t.row)
+	}
+	t.row, t.inRow = nil, false
+}
+
+func (t *htmlTable) startCell(tok html.Token) {
+	t.endCell()
+	if !t.inRow {
+		t.startRow()
+	}
+	t.cell = &htmlCell{
+		colspan: spanAttr(tok, "colspan"),
+		rowspan: spanAttr(tok, "rowspan"),
+	}
+	t.buf.Reset()
+}
+
+func (t *htmlTable) endCell() {
+	if t.cell == nil {
+		return
+	}
+	// пробелы, переносы и &nbsp; внутри ячейки схлопываем
+	t.cell.text = strings.Join(strings.Fields(t.buf.String()), " ")
+	t.row = append(t.row, *t.cell)
+	t.cell = nil
+}
+
+func (t *htmlTable) text(s string) {
+	if t.cell != nil {
+		t.buf.WriteString(s)
+	}
+}
+
+// grid раскрывает colspan/rowspan: значение объединенной ячейки
+// повторяется во всех ячейках, которые она занимает
+func (t *htmlTable) grid() [][]string {
+	var grid [][]string
+	taken := make(map[[2]int]bool) // занято ячейкой из строки выше
+
+	put := func(r, c int, s string) {
+		for len(grid) <= r {
+			grid = append(grid, nil)
+		}
+		for len(grid[r]) <= c {
+			grid[r] = append(grid[r], "")
+		}
+		grid[r][c] = s
+		taken[[2]int{r, c}] = true
+	}
+
+	for r, row := range t.rows {
+		for len(grid) <= r {
+			grid = append(grid, nil)
+		}
+		c := 0
+		for _, cell := range row {
+			for taken[[2]int{r, c}] {
+				c++
+			}
+			for dr := 0; dr < cell.rowspan && r+dr < len(t.rows); dr++ {
+				for dc := 0; dc < cell.colspan; dc++ {
+					put(r+dr, c+dc, cell.text)
+				}
+			}
+			c += cell.colspan
+		}
+	}
+	return grid
+}
+
+func spanAttr(tok html.Token, name string) int {
+	for _, a := range tok.Attr {
+		if a.Key != name {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(a.Val))
+		if err != nil || n < 1 {
+			return 1
+		}
+		return min(n, maxHTMLSpan)
+	}
+	return 1
+}
+
+// readHTMLTable читает таблицу ответа из HTML. Если таблиц несколько
+// (например, таблица разметки страницы), берется та, где нашелся заголовок.
+func readHTMLTable(filename string, aliases ColumnAliases) ([][]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := parseHTMLTables(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("таблица <table> не найдена в HTML")
+	}
+
+	// без подходящей таблицы отдаем самую большую,
+	// чтобы в ошибке были перечислены недостающие колонки
+	largest := tables[0]
+	for _, rows := range tables {
+		if _, err := findColumns(rows, aliases); err == nil {
+			return rows, nil
+		}
+		if len(rows) > len(largest) {
+			largest = rows
+		}
+	}
+	return largest, nil
+}
+
+// parseHTMLTables возвращает все непустые таблицы документа.
+// Вложенная таблица разбирается отдельно и не попадает в ячейку внешней.
+func parseHTMLTables(data []byte) ([][][]string, error) {
+	enc := htmlEncoding(data)
+	z := html.NewTokenizer(enc.NewDecoder().Reader(bytes.NewReader(data)))
+
+	var stack []*htmlTable
+	var tables [][][]string
+
+	closeTable := func() {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		t.endRow()
+		if len(t.rows) > 0 {
+			tables = append(tables, t.grid())
+		}
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return nil, fmt.Errorf("ошибка чтения HTML: %w", err)
+			}
+			break
+		}
+
+		tok := z.Token()
+		if tok.DataAtom == atom.Table {
+			switch tt {
+			case html.StartTagToken:
+				stack = append(stack, &htmlTable{})
+			case html.EndTagToken:
+				if len(stack) > 0 {
+					closeTable()
+				}
+			}
+			continue
+		}
+		if len(stack) == 0 {
+			continue
+		}
+		t := stack[len(stack)-1]
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch tok.DataAtom {
+			case atom.Tr:
+				t.startRow()
+			case atom.Td, atom.Th:
+				t.startCell(tok)
+			case atom.Br, atom.P, atom.Div, atom.Li:
+				t.text(" ")
+			}
+		case html.EndTagToken:
+			switch tok.DataAtom {
+			case atom.Tr, atom.Thead, atom.Tbody, atom.Tfoot:
+				t.endRow()
+			case atom.Td, atom.Th:
+				t.endCell()
+			case atom.P, atom.Div, atom.Li:
+				t.text(" ")
+			}
+		case html.TextToken:
+			// сущности (&quot;, &#1060;, &nbsp;) токенизатор уже раскрыл
+			t.text(tok.Data)
+		}
+	}
+
+	// незакрытые таблицы в конце файла
+	for len(stack) > 0 {
+		closeTable()
+	}
+	return tables, nil
+}
+
+// htmlEncoding определяет кодировку по BOM и <meta charset>.
+// Без объявления: UTF-8, если текст корректен, иначе windows-1251.
+func htmlEncoding(data []byte) encoding.Encoding {
+	enc, name, _ := charset.DetermineEncoding(data, "")
+	// windows-1252 — ответ по умолчанию, для русских выгрузок он не подходит
+	if name == "windows-1252" {
+		if utf8.Valid(data) {
+			return encoding.Nop
+		}
+		return charmap.Windows1251
+	}
+	return enc
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// строка данных после заголовка: Иванов Иван Иванович 1985-03-05, розыск ДА
+const htmlDataRow = `<tr><td>Иванов</td><td>Иван</td><td>Иванович</td>
+<td>1985</td><td>3</td><td>05</td><td>ДА</td></tr>`
+
+const htmlHeaders = `<th>Фамилия</th><th>Имя</th><th>Отчество</th>
+<th>Год рождения</th><th>Месяц рождения</th><th>День рождения</th>`
+
+func TestReadHTMLTable(t *testing.T) {
+	cp1251, err := charmap.Windows1251.NewEncoder().String(`<html><head>
+<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">
+</head><body><table>
+<tr>` + htmlHeaders + `<th>Розыск лиц</th></tr>` + htmlDataRow + `
+</table></body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		page string
+	}{
+		{"заголовок в две строки", `<table>
+<tr><th rowspan="2">Фамилия</th><th rowspan="2">Имя</th><th rowspan="2">Отчество</th>
+<th colspan="3">Дата рождения</th><th>Проверки</th></tr>
+<tr><th>Год рождения</th><th>Месяц рождения</th><th>День рождения</th><th>Розыск лиц</th></tr>
+` + htmlDataRow + `</table>`},
+		{"вложенная таблица", `<table><tr><td>Ответ ИБД-Ф</td></tr><tr><td>
+<table><tr>` + htmlHeaders + `<th>Розыск лиц</th></tr>` + htmlDataRow + `</table>
+</td></tr><tr><td>Исполнитель: Петров</td></tr></table>`},
+		{"windows-1251 с meta charset", cp1251},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ответ.xls")
+			if err := os.WriteFile(path, []byte(tt.page), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := readHTMLTable(path, DefaultColumnAliases())
+			if err != nil {
+				t.Fatal(err)
+			}
+			mapping, err := findColumns(rows, DefaultColumnAliases())
+			if err != nil {
+				t.Fatalf("findColumns: %v, таблица %q", err, rows)
+			}
+			if mapping.headerRow+1 >= len(rows) {
+				t.Fatalf("после заголовка (строка %d) нет данных: %q", mapping.headerRow, rows)
+			}
+
+			var row XLSRow
+			for c, value := range rows[mapping.headerRow+1] {
+				if field, ok := mapping.fields[c]; ok {
+					*field(&row) = value
+				}
+			}
+			want := map[string]string{
+				"Фамилия": "Иванов", "Имя": "Иван", "Отчество": "Иванович",
+				"Год рождения": "1985", "Месяц рождения": "3", "День рождения": "05",
+				"Розыск лиц": "ДА",
+			}
+			for header, value := range want {
+				if got := row.Value(header); got != value {
+					t.Errorf("%s = %q, ожидалось %q", header, got, value)
+				}
+			}
+		})
+	}
+}
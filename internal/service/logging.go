@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ===== ЖУРНАЛ =====
+
+// LogOptions — настройки журнала
+type LogOptions struct {
+	Level        string `json:"level"`         // debug, info, warn, error
+	PersonalData bool   `json:"personal_data"` // писать ФИО и даты рождения без маскировки
+	MaxSizeMB    int    `json:"max_size_mb"`   // размер файла, после которого он ротируется
+	MaxBackups   int    `json:"max_backups"`   // сколько старых файлов хранить
+}
+
+func DefaultLogOptions() LogOptions {
+	return LogOptions{
+		Level:      "info",
+		MaxSizeMB:  5,
+		MaxBackups: 3,
+	}
+}
+
+// LogPath — файл журнала в профиле пользователя
+func LogPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "GOsuslugiXML", "logs", "gosuslugi.log"), nil
+}
+
+// NewLogger создает текстовый журнал в w с уровнем из настроек
+func NewLogger(w io.Writer, opts LogOptions) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// OpenUserLog открывает журнал в профиле пользователя с ротацией файла
+func OpenUserLog(opts LogOptions) (*slog.Logger, io.Closer, error) {
+	path, err := LogPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := OpenRotatingFile(path, int64(opts.MaxSizeMB)<<20, opts.MaxBackups)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewLogger(file, opts), file, nil
+}
+
+// discardLogger — журнал по умолчанию, никуда не пишет
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// SetLogger задает журнал парсера, nil — не вести журнал
+func (x *XmlParser) SetLogger(log *slog.Logger) {
+	if log == nil {
+		log = discardLogger
+	}
+	x.log = log
+}
+
+// personal — персональные данные для журнала: по умолчанию остается
+// только первая буква ("И***"), полностью — если разрешено в настройках
+func (x *XmlParser) personal(s string) string {
+	if x.cfg.Log.PersonalData {
+		return s
+	}
+	return redact(s)
+}
+
+func redact(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return string(r) + "***"
+}
+
+// ===== РОТАЦИЯ ФАЙЛА ЖУРНАЛА =====
+
+// RotatingFile — файл журнала, который при превышении размера
+// переименовывается в .1 (.1 в .2 и т.д.), старше MaxBackups удаляются
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+func OpenRotatingFile(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	r := &RotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, fmt.Errorf("журнал закрыт")
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	if r.backups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+		for i := r.backups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		}
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
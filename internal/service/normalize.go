@@ -0,0 +1,95 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ===== НОРМАЛИЗАЦИЯ ФИО =====
+
+// NormalizeOptions — какие различия в написании ФИО не мешают сравнению.
+// И XML, и XLS проходят через одни и те же правила перед построением ключа.
+type NormalizeOptions struct {
+	IgnoreCase      bool `json:"ignore_case"`      // ИВАНОВ = Иванов
+	YoToE           bool `json:"yo_to_e"`          // Семёнов = Семенов
+	CollapseSpaces  bool `json:"collapse_spaces"`  // двойные и крайние пробелы
+	HyphenSpacing   bool `json:"hyphen_spacing"`   // "Иванова - Петрова" = "Иванова-Петрова"
+	LatinLookalikes bool `json:"latin_lookalikes"` // латинские C, O, P... в кириллице
+}
+
+func DefaultNormalizeOptions() NormalizeOptions {
+	return NormalizeOptions{
+		IgnoreCase:      true,
+		YoToE:           true,
+		CollapseSpaces:  true,
+		HyphenSpacing:   true,
+		LatinLookalikes: true,
+	}
+}
+
+// латинские буквы, которые пишутся так же, как кириллические
+var lookalikePairs = []string{
+	"A", "А", "B", "В", "C", "С", "E", "Е", "H", "Н", "K", "К", "M", "М",
+	"O", "О", "P", "Р", "T", "Т", "X", "Х", "Y", "У",
+	"a", "а", "c", "с", "e", "е", "o", "о", "p", "р", "x", "х", "y", "у",
+}
+
+var (
+	latinLookalikes = strings.NewReplacer(lookalikePairs...)
+	// для сравнения без учета регистра: та же таблица в нижнем регистре,
+	// чтобы "KIM", "Kim" и "КИМ" давали одно и то же
+	latinLookalikesLower = strings.NewReplacer(lowerPairs(lookalikePairs)...)
+)
+
+func lowerPairs(pairs []string) []string {
+	var out []string
+	for i := 0; i < len(pairs); i += 2 {
+		from, to := strings.ToLower(pairs[i]), strings.ToLower(pairs[i+1])
+		if !slices.Contains(out, from) {
+			out = append(out, from, to)
+		}
+	}
+	return out
+}
+
+// длинные и короткие тире, минус и т.п. считаем дефисом
+var dashes = strings.NewReplacer("‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "−", "-")
+
+// Name приводит часть ФИО к виду для сравнения
+func (o NormalizeOptions) Name(s string) string {
+	if o.IgnoreCase {
+		s = strings.ToLower(s)
+	}
+	if o.LatinLookalikes {
+		if o.IgnoreCase {
+			s = latinLookalikesLower.Replace(s)
+		} else {
+			s = latinLookalikes.Replace(s)
+		}
+	}
+	if o.YoToE {
+		s = strings.NewReplacer("ё", "е", "Ё", "Е").Replace(s)
+	}
+	if o.CollapseSpaces {
+		s = strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+	}
+	if o.HyphenSpacing {
+		s = dashes.Replace(s)
+		parts := strings.Split(s, "-")
+		for i, part := range parts {
+			parts[i] = strings.TrimSpace(part)
+		}
+		s = strings.Join(parts, "-")
+	}
+	return s
+}
+
+// FIO нормализует все части ФИО
+func (o NormalizeOptions) FIO(fio FIO) FIO {
+	return FIO{
+		Surname:    o.Name(fio.Surname),
+		Name:       o.Name(fio.Name),
+		Patronymic: o.Name(fio.Patronymic),
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	norm := DefaultNormalizeOptions()
+
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"регистр", "ИВАНОВ", "иванов"},
+		{"регистр внутри слова", "ИвАнОв", "Иванов"},
+		{"ё и е", "Семёнов", "Семенов"},
+		{"Ё заглавная", "ЁЛКИН", "Елкин"},
+		{"двойные пробелы", "Анна  Мария", "Анна Мария"},
+		{"крайние пробелы", "  Иванов ", "Иванов"},
+		{"табуляция", "Анна\tМария", "Анна Мария"},
+		{"пробелы вокруг дефиса", "Иванова - Петрова", "Иванова-Петрова"},
+		{"тире вместо дефиса", "Иванова — Петрова", "Иванова-Петрова"},
+		{"латинские C и O", "CОКОЛОВ", "СОКОЛОВ"},
+		{"латинские строчные", "Соpокин", "Сорокин"},
+		{"латиница целиком", "KOT", "Кот"},
+		{"латиница с заглавной", "Kot", "КОТ"},
+		{"латиница разного регистра", "KIM", "Kim"},
+		{"латинские H T B M", "HТBM", "нтвм"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if a, b := norm.Name(tt.a), norm.Name(tt.b); a != b {
+				t.Errorf("Name(%q) = %q, Name(%q) = %q, ожидалось равенство", tt.a, a, tt.b, b)
+			}
+		})
+	}
+}
+
+func TestNormalizeNameDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		opts NormalizeOptions
+		a, b string
+	}{
+		{"регистр", NormalizeOptions{}, "ИВАНОВ", "Иванов"},
+		{"ё и е", NormalizeOptions{IgnoreCase: true}, "Семёнов", "Семенов"},
+		{"пробелы", NormalizeOptions{IgnoreCase: true}, "Анна  Мария", "Анна Мария"},
+		{"дефис", NormalizeOptions{CollapseSpaces: true}, "Иванова - Петрова", "Иванова-Петрова"},
+		{"латиница", NormalizeOptions{IgnoreCase: true}, "KIM", "Ким"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if a, b := tt.opts.Name(tt.a), tt.opts.Name(tt.b); a == b {
+				t.Errorf("Name(%q) = Name(%q) = %q, правило выключено", tt.a, tt.b, a)
+			}
+		})
+	}
+}
+
+func TestNormalizeFIO(t *testing.T) {
+	norm := DefaultNormalizeOptions()
+
+	got := norm.FIO(FIO{Surname: " СЕМЁНОВ ", Name: "Kим", Patronymic: "Петрович  "})
+	want := FIO{Surname: "семенов", Name: "ким", Patronymic: "петрович"}
+	if got != want {
+		t.Errorf("FIO() = %+v, ожидалось %+v", got, want)
+	}
+}
+
+// XML и XLS проходят через одни правила: разное написание дает один ключ
+func TestNormalizeKeysXMLAndXLS(t *testing.T) {
+	norm := DefaultNormalizeOptions()
+
+	tests := []struct {
+		name string
+		xml  FIO
+		xls  FIO
+	}{
+		{"регистр",
+			FIO{"Иванов", "Иван", "Иванович"},
+			FIO{"ИВАНОВ", "ИВАН", "ИВАНОВИЧ"}},
+		{"ё и е",
+			FIO{"Семёнов", "Пётр", "Алексеевич"},
+			FIO{"Семенов", "Петр", "Алексеевич"}},
+		{"пробелы",
+			FIO{"Иванов ", "Анна  Мария", "Ивановна"},
+			FIO{" Иванов", "Анна Мария", "Ивановна "}},
+		{"двойная фамилия",
+			FIO{"Иванова-Петрова", "Анна", "Ивановна"},
+			FIO{"Иванова - Петрова", "Анна", "Ивановна"}},
+		{"латинские буквы в XLS",
+			FIO{"Соколов", "Олег", "Петрович"},
+			FIO{"Cокoлов", "Oлег", "Пeтрович"}},
+		{"латинские буквы в XML",
+			FIO{"KOT", "Tимур", "Kимович"},
+			FIO{"Кот", "Тимур", "Кимович"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := Document{DocNumber: "1"}
+			doc.RequestInfo.ConvictionPerson = ConvictionPerson{
+				CPSurname:    tt.xml.Surname,
+				CPName:       tt.xml.Name,
+				CPPatronymic: tt.xml.Patronymic,
+				CPBirthday:   "05.03.1985",
+			}
+			row := XLSRow{
+				Surname:    tt.xls.Surname,
+				Name:       tt.xls.Name,
+				Patronymic: tt.xls.Patronymic,
+				BirthYear:  "1985",
+				BirthMonth: "3",
+				BirthDay:   "05",
+			}
+
+			keys := documentKeys(doc, norm)
+			if len(keys) != 1 {
+				t.Fatalf("documentKeys() = %q, ожидался один ключ", keys)
+			}
+			if key := rowKey(row, norm); key != keys[0] {
+				t.Errorf("ключ XLS %q, ключ XML %q", key, keys[0])
+			}
+		})
+	}
+}
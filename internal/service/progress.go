@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ===== ХОД ВЫПОЛНЕНИЯ =====
+
+// этапы долгих операций
+const (
+	StageParse = "Разбор XML"
+	StageRead  = "Чтение ответа ИБД-Ф"
+	StageMatch = "Сравнение"
+	StageFuzzy = "Нечеткий поиск"
+	StageWrite = "Запись отчета"
+)
+
+// Progress — событие хода выполнения
+type Progress struct {
+	Stage string
+	Done  int   // обработано документов или строк
+	Total int   // всего, 0 — заранее неизвестно
+	Bytes int64 // прочитано байт XML при разборе
+	Size  int64 // размер XML, 0 — неизвестен
+}
+
+// Fraction — доля выполненного от 0 до 1, по строкам или по байтам XML
+func (p Progress) Fraction() float64 {
+	switch {
+	case p.Total > 0:
+		return float64(p.Done) / float64(p.Total)
+	case p.Size > 0:
+		return float64(p.Bytes) / float64(p.Size)
+	}
+	return 0
+}
+
+func (p Progress) String() string {
+	unit := "строк"
+	if p.Stage == StageParse {
+		unit = "документов"
+	}
+	if p.Total > 0 {
+		return fmt.Sprintf("%s — %s: %d из %d", p.Stage, unit, p.Done, p.Total)
+	}
+	return fmt.Sprintf("%s — %s: %d", p.Stage, unit, p.Done)
+}
+
+// ProgressFunc получает события хода выполнения. Вызывается из той же
+// горутины, что и операция, и часто (на каждую строку) — не должна блокировать.
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress возвращает контекст, через который Context-варианты функций
+// сообщают в fn о ходе разбора, чтения, сравнения и записи отчета
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func progress(ctx context.Context, p Progress) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(p)
+	}
+}
+
+// progressStep — проверка отмены и событие перед обработкой очередной строки
+func progressStep(ctx context.Context, stage string, done, total int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	progress(ctx, Progress{Stage: stage, Done: done, Total: total})
+	return nil
+}
+
+// countingReader считает прочитанные байты для хода разбора XML
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,538 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ===== ФОРМАТЫ ОТЧЕТА =====
+
+// ReportWriter пишет результат сравнения в одном из форматов
+type ReportWriter interface {
+	// Extension — расширение файла отчета вместе с точкой
+	Extension() string
+	// Write записывает отчет и возвращает его листы (разделы) с числом строк.
+	// Проверяет отмену ctx между строками и возвращает ctx.Err(),
+	// о ходе записи сообщает через WithProgress
+	Write(ctx context.Context, w io.Writer, res *MatchResult, opts ReportOptions) ([]SheetRows, error)
+}
+
+// форматы отчета, пустой формат — Excel
+const (
+	FormatExcel = "xlsx"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatHTML  = "html"
+)
+
+var reportWriters = map[string]ReportWriter{
+	FormatExcel: excelReportWriter{},
+	FormatCSV:   csvReportWriter{},
+	FormatJSON:  jsonReportWriter{},
+	FormatHTML:  htmlReportWriter{},
+}
+
+// ReportFormats — доступные форматы отчета для выбора в CLI и GUI
+func ReportFormats() []string {
+	return []string{FormatExcel, FormatCSV, FormatJSON, FormatHTML}
+}
+
+// NewReportWriter возвращает writer для формата ("" — Excel)
+func NewReportWriter(format string) (ReportWriter, error) {
+	if format == "" {
+		format = FormatExcel
+	}
+	w, ok := reportWriters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный формат отчета %q, доступны: %s",
+			format, strings.Join(ReportFormats(), ", "))
+	}
+	return w, nil
+}
+
+// FormatFromPath — формат отчета по расширению файла, "" если не распознан
+func FormatFromPath(path string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if ext == "htm" {
+		return FormatHTML
+	}
+	if _, ok := reportWriters[ext]; ok {
+		return ext
+	}
+	return ""
+}
+
+// ===== ИМЯ И ПУТЬ ОТЧЕТА =====
+
+// DefaultNameTemplate — имя отчета без расширения. Подстановки:
+// {xml} — имя XML файла, {date} — дата, {time} — время, {batch} — номер партии
+const DefaultNameTemplate = "gosuslugi_{date}_{time}"
+
+// попыток подобрать свободное имя "отчет (N)"
+const maxReportCopies = 1000
+
+// символы, недопустимые в имени файла Windows
+var fileNameReplacer = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_",
+)
+
+// ReportFileName — имя файла отчета по шаблону, с расширением формата
+func ReportFileName(opts ReportOptions, now time.Time) (string, error) {
+	w, err := NewReportWriter(opts.Format)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl := opts.NameTemplate
+	if tmpl == "" {
+		tmpl = DefaultNameTemplate
+	}
+	xmlName := strings.TrimSuffix(filepath.Base(opts.XMLFile), filepath.Ext(opts.XMLFile))
+	if opts.XMLFile == "" {
+		xmlName = ""
+	}
+	batch := ""
+	if opts.BatchNumber > 0 {
+		batch = strconv.Itoa(opts.BatchNumber)
+	}
+
+	name := strings.NewReplacer(
+		"{xml}", xmlName,
+		"{date}", now.Format("02.01.2006"),
+		"{time}", now.Format("15-04-05"),
+		"{batch}", batch,
+	).Replace(tmpl)
+	// пустые подстановки не должны оставлять "_" по краям
+	name = strings.Trim(fileNameReplacer.Replace(name), " ._-")
+	if name == "" {
+		name = "gosuslugi"
+	}
+	return name + w.Extension(), nil
+}
+
+// ReportPath — куда будет записан отчет: opts.OutPath, иначе opts.Dir,
+// иначе папка файла ИБД-Ф. OutPath с расширением — файл отчета; без
+// расширения или с разделителем в конце — папка. В папке имя по шаблону,
+// недостающие папки создаются.
+func ReportPath(filename string, opts ReportOptions) (string, error) {
+	name, err := ReportFileName(opts, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(filename)
+	switch {
+	case opts.OutPath != "" && isReportFile(opts.OutPath):
+		if FormatFromPath(opts.OutPath) != FormatFromPath(name) {
+			return "", fmt.Errorf("расширение %s не соответствует формату отчета %s",
+				filepath.Ext(opts.OutPath), strings.TrimPrefix(filepath.Ext(name), "."))
+		}
+		return opts.OutPath, nil
+	case opts.OutPath != "":
+		dir = opts.OutPath
+	case opts.Dir != "":
+		dir = opts.Dir
+	default:
+		return filepath.Join(dir, name), nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// isReportFile — путь указывает на файл отчета, а не на папку
+func isReportFile(path string) bool {
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return false
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return false
+	}
+	return filepath.Ext(path) != ""
+}
+
+// ReportResult — что записано в отчет
+type ReportResult struct {
+	Path      string
+	Sheets    []SheetRows // строк на каждом листе (разделе) отчета
+	Positive  int         // строк с положительным результатом
+	Unmatched int         // строк без документа XML: требуют проверки и нет в XML
+}
+
+// SheetRows — число строк данных на листе, без заголовка
+type SheetRows struct {
+	Sheet string
+	Rows  int
+}
+
+// CreateReport создает отчет (по умолчанию рядом с файлом ИБД-Ф).
+// Существующий файл не перезаписывается: к имени добавляется " (N)".
+func CreateReport(filename string, res *MatchResult, opts ReportOptions) (*ReportResult, error) {
+	return CreateReportContext(context.Background(), filename, res, opts)
+}
+
+// CreateReportContext — CreateReport с отменой и ходом выполнения через ctx,
+// недописанный файл отчета удаляется
+func CreateReportContext(ctx context.Context, filename string, res *MatchResult, opts ReportOptions) (*ReportResult, error) {
+	w, err := NewReportWriter(opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	path, err := ReportPath(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	file, path, err := createUnique(path)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewWriter(file)
+	sheets, err := w.Write(ctx, buf, res, opts)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := buf.Flush(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	result := reportCounts(res, opts)
+	result.Path = path
+	result.Sheets = sheets
+	return result, nil
+}
+
+// reportCounts — итоги по строкам, листы заполняет писатель отчета
+func reportCounts(res *MatchResult, opts ReportOptions) *ReportResult {
+	summary := res.Summary()
+	return &ReportResult{
+		Positive:  positiveCount(res.Rows, opts.rules()),
+		Unmatched: summary.Fuzzy + summary.Orphans,
+	}
+}
+
+func positiveCount(rows []XLSRow, rules PositiveRules) int {
+	n := 0
+	for _, row := range rows {
+		if rules.IsPositive(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// String — краткий итог для пользователя
+func (r *ReportResult) String() string {
+	parts := make([]string, len(r.Sheets))
+	for i, s := range r.Sheets {
+		parts[i] = fmt.Sprintf("%s: %d", s.Sheet, s.Rows)
+	}
+	return fmt.Sprintf("Положительных: %d, без документа XML: %d\n%s",
+		r.Positive, r.Unmatched, strings.Join(parts, "\n"))
+}
+
+// createUnique создает новый файл, подбирая свободное имя
+func createUnique(path string) (*os.File, string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for i := 0; i < maxReportCopies; i++ {
+		candidate := path
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+		}
+		file, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return file, candidate, err
+	}
+	return nil, "", fmt.Errorf("не удалось подобрать свободное имя для %s", path)
+}
+
+// раздел CSV и JSON отчетов: одна таблица строк ответа
+const tableSection = "Строки ответа ИБД-Ф"
+
+// ===== CSV =====
+
+// csvReportWriter — основной лист отчета; разделитель ";" и BOM,
+// чтобы русский Excel открыл файл без мастера импорта
+type csvReportWriter struct{}
+
+func (csvReportWriter) Extension() string { return ".csv" }
+
+func (csvReportWriter) Write(ctx context.Context, w io.Writer, res *MatchResult, opts ReportOptions) ([]SheetRows, error) {
+	if _, err := w.Write(bomUTF8); err != nil {
+		return nil, err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	cw.UseCRLF = true
+
+	rules := opts.rules()
+	cw.Write(append(reportHeaders(opts), "Положительный результат"))
+	for i, row := range res.Rows {
+		if err := progressStep(ctx, StageWrite, i+1, len(res.Rows)); err != nil {
+			return nil, err
+		}
+		positive := ""
+		if rules.IsPositive(row) {
+			positive = "ДА"
+		}
+		cw.Write(append(reportRowData(row, opts), positive))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	return []SheetRows{{tableSection, len(res.Rows)}}, nil
+}
+
+// ===== JSON =====
+
+type jsonReportWriter struct{}
+
+func (jsonReportWriter) Extension() string { return ".json" }
+
+// строка отчета в JSON: поля ИБД-Ф, статус сравнения и сведения из XML
+type jsonReportRow struct {
+	DocumentNumber  string           `json:"document_number"`
+	Surname         string           `json:"surname"`
+	Name            string           `json:"name"`
+	Patronymic      string           `json:"patronymic"`
+	BirthYear       string           `json:"birth_year"`
+	BirthMonth      string           `json:"birth_month"`
+	BirthDay        string           `json:"birth_day"`
+	Result          string           `json:"result"`
+	WantedPersons   string           `json:"wanted_persons"`
+	OSKRegion       string           `json:"osk_region"`
+	OSKGIAZ         string           `json:"osk_giac"`
+	AdminPracticeR  string           `json:"admin_practice_region"`
+	AdminPracticeF  string           `json:"admin_practice_fism"`
+	ZAGSDeath       string           `json:"zags_death"`
+	Restricted      string           `json:"restricted"`
+	PassportRF      string           `json:"passport_rf"`
+	DeportationMode string           `json:"deportation_mode"`
+	Status          string           `json:"status"`
+	Positive        bool             `json:"positive"`
+	Ambiguous       int              `json:"ambiguous,omitempty"`
+	Candidates      []jsonCandidate  `json:"candidates,omitempty"`
+	Details         *DocumentDetails `json:"details,omitempty"`
+}
+
+type jsonCandidate struct {
+	DocNumber  string  `json:"document_number"`
+	FIO        string  `json:"fio"`
+	Birthday   string  `json:"birthday"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+func (jsonReportWriter) Write(ctx context.Context, w io.Writer, res *MatchResult, opts ReportOptions) ([]SheetRows, error) {
+	rules := opts.rules()
+	rows := make([]jsonReportRow, 0, len(res.Rows))
+	for i, row := range res.Rows {
+		if err := progressStep(ctx, StageWrite, i+1, len(res.Rows)); err != nil {
+			return nil, err
+		}
+		out := jsonReportRow{
+			DocumentNumber:  row.DocumentNumber,
+			Surname:         row.Surname,
+			Name:            row.Name,
+			Patronymic:      row.Patronymic,
+			BirthYear:       row.BirthYear,
+			BirthMonth:      row.BirthMonth,
+			BirthDay:        row.BirthDay,
+			Result:          row.Result,
+			WantedPersons:   row.WantedPersons,
+			OSKRegion:       row.OSKRegion,
+			OSKGIAZ:         row.OSKGIAZ,
+			AdminPracticeR:  row.AdminPracticeR,
+			AdminPracticeF:  row.AdminPracticeF,
+			ZAGSDeath:       row.ZAGSDeath,
+			Restricted:      row.Restricted,
+			PassportRF:      row.PassportRF,
+			DeportationMode: row.DeportationMode,
+			Status:          row.Status(),
+			Positive:        rules.IsPositive(row),
+			Ambiguous:       row.Ambiguous,
+		}
+		for _, c := range row.Candidates {
+			out.Candidates = append(out.Candidates, jsonCandidate(c))
+		}
+		if opts.ExtraColumns {
+			out.Details = row.Details
+		}
+		rows = append(rows, out)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rows); err != nil {
+		return nil, err
+	}
+	return []SheetRows{{tableSection, len(rows)}}, nil
+}
+
+// ===== HTML =====
+
+// htmlReportWriter — страница для печати с теми же разделами, что и листы Excel
+type htmlReportWriter struct{}
+
+func (htmlReportWriter) Extension() string { return ".html" }
+
+// ячейка HTML отчета с классом подсветки
+type htmlReportCell struct {
+	Value string
+	Class string
+}
+
+type htmlReportRow struct {
+	Positive bool // подсветить строку целиком
+	Cells    []htmlReportCell
+}
+
+type htmlReportSection struct {
+	Title   string
+	Headers []string
+	Rows    []htmlReportRow
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Сравнение с ИБД-Ф</title>
+<style>
+body { font-family: Arial, sans-serif; font-size: 12px; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #000; padding: 2px 4px; }
+th { background: #D9E1F2; }
+tr.positive td { background: #FFFF00; }
+td.positive, tr.positive td.positive { background: #FF6600; font-weight: bold; }
+td.status { background: #FFC7CE; }
+@media print { h2 { page-break-before: always; } }
+</style>
+</head>
+<body>
+<h1>Сравнение с ИБД-Ф</h1>
+<p>Создан {{.Created}}. {{.Summary}}</p>
+{{range .Sections}}{{if .Rows}}
+<h2>{{.Title}} ({{len .Rows}})</h2>
+<table>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr{{if .Positive}} class="positive"{{end}}>{{range .Cells}}<td{{if .Class}} class="{{.Class}}"{{end}}>{{.Value}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+func (htmlReportWriter) Write(ctx context.Context, w io.Writer, res *MatchResult, opts ReportOptions) ([]SheetRows, error) {
+	headers := reportHeaders(opts)
+	rules := opts.rules()
+	statusCol := slices.Index(headers, "Статус")
+
+	var main, positive []htmlReportRow
+	for i, row := range res.Rows {
+		if err := progressStep(ctx, StageWrite, i+1, len(res.Rows)); err != nil {
+			return nil, err
+		}
+		values := reportRowData(row, opts)
+		out := htmlReportRow{Positive: rules.IsPositive(row), Cells: make([]htmlReportCell, len(values))}
+		for j, value := range values {
+			out.Cells[j].Value = value
+			if rule, ok := rules.ForColumn(headers[j]); ok && rule.Match(value) {
+				out.Cells[j].Class = "positive"
+			}
+			if j == statusCol && value != StatusMatched {
+				out.Cells[j].Class = "status"
+			}
+		}
+		main = append(main, out)
+		if out.Positive {
+			positive = append(positive, out)
+		}
+	}
+
+	sections := []htmlReportSection{
+		{Title: "Результат сравнения", Headers: headers, Rows: main},
+		{Title: positiveSheet, Headers: headers, Rows: positive},
+		{Title: documentSheet, Headers: documentHeaders(), Rows: documentHTMLRows(res, rules)},
+		{Title: reviewSheet, Headers: reviewHeaders, Rows: plainRows(reviewRows(res.Rows))},
+		{Title: orphanSheet, Headers: headers, Rows: plainRows(orphanRows(res.Rows, opts))},
+		{Title: missingSheet, Headers: missingHeaders, Rows: plainRows(missingRows(res.Missing))},
+	}
+
+	err := htmlReportTemplate.Execute(w, struct {
+		Created  string
+		Summary  string
+		Sections []htmlReportSection
+	}{
+		Created:  time.Now().Format("02.01.2006 15:04"),
+		Summary:  res.Summary().String(),
+		Sections: sections,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// пустые разделы в страницу не выводятся
+	var sheets []SheetRows
+	for _, section := range sections {
+		if len(section.Rows) > 0 {
+			sheets = append(sheets, SheetRows{section.Title, len(section.Rows)})
+		}
+	}
+	return sheets, nil
+}
+
+func documentHTMLRows(res *MatchResult, rules PositiveRules) []htmlReportRow {
+	rows := documentRows(res, rules)
+	out := make([]htmlReportRow, len(rows))
+	for i, row := range rows {
+		out[i] = htmlReportRow{Positive: row.verdict == VerdictPositive, Cells: make([]htmlReportCell, len(row.values))}
+		for j, value := range row.values {
+			out[i].Cells[j].Value = value
+			if row.positive[j] {
+				out[i].Cells[j].Class = "positive"
+			}
+		}
+	}
+	return out
+}
+
+func plainRows(rows [][]string) []htmlReportRow {
+	out := make([]htmlReportRow, len(rows))
+	for i, row := range rows {
+		out[i].Cells = make([]htmlReportCell, len(row))
+		for j, value := range row {
+			out[i].Cells[j].Value = value
+		}
+	}
+	return out
+}
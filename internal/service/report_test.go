@@ -0,0 +1,60 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReportPath(t *testing.T) {
+	tmp := t.TempDir()
+	existing := filepath.Join(tmp, "есть")
+	if err := os.Mkdir(existing, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	opts := ReportOptions{Format: "csv", NameTemplate: "отчет"}
+	ibdf := filepath.Join(tmp, "ответ.xls")
+
+	tests := []struct {
+		name    string
+		out     string
+		dir     string
+		want    string // "" — ожидается ошибка
+		created string // папка, которая должна появиться
+	}{
+		{"по умолчанию рядом с ИБД-Ф", "", "", filepath.Join(tmp, "отчет.csv"), ""},
+		{"папка отчетов из настроек", "", filepath.Join(tmp, "отчеты"), filepath.Join(tmp, "отчеты", "отчет.csv"), filepath.Join(tmp, "отчеты")},
+		{"существующая папка", existing, "", filepath.Join(existing, "отчет.csv"), ""},
+		{"новая папка без расширения", filepath.Join(tmp, "новая"), "", filepath.Join(tmp, "новая", "отчет.csv"), filepath.Join(tmp, "новая")},
+		{"разделитель в конце", filepath.Join(tmp, "2024.10") + string(filepath.Separator), "", filepath.Join(tmp, "2024.10", "отчет.csv"), filepath.Join(tmp, "2024.10")},
+		{"файл отчета", filepath.Join(tmp, "итог.csv"), filepath.Join(tmp, "не нужна"), filepath.Join(tmp, "итог.csv"), ""},
+		{"расширение другого формата", filepath.Join(tmp, "итог.xlsx"), "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			o.OutPath, o.Dir = tt.out, tt.dir
+			got, err := ReportPath(ibdf, o)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("ReportPath() = %q, ожидалась ошибка", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ReportPath() = %q, ожидалось %q", got, tt.want)
+			}
+			if tt.created != "" {
+				if info, err := os.Stat(tt.created); err != nil || !info.IsDir() {
+					t.Errorf("папка %q не создана", tt.created)
+				}
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "не нужна")); err == nil {
+		t.Error("папка из настроек создана, хотя указан файл отчета")
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ===== ПРАВИЛА ПОЛОЖИТЕЛЬНОГО РЕЗУЛЬТАТА =====
+
+// PositiveRule — какие значения в колонке проверки считаются положительными
+type PositiveRule struct {
+	Column string   `json:"column"` // каноническое название колонки, например "Розыск лиц"
+	Values []string `json:"values"` // значения, например "ДА", "да", "+", "есть"
+}
+
+type PositiveRules []PositiveRule
+
+// DefaultPositiveRules — «ДА» в розыске и ОСК
+func DefaultPositiveRules() PositiveRules {
+	return PositiveRules{
+		{Column: "Розыск лиц", Values: []string{"ДА"}},
+		{Column: "ОСК регион", Values: []string{"ДА"}},
+		{Column: "ОСК ГИАЦ", Values: []string{"ДА"}},
+	}
+}
+
+// Match проверяет значение ячейки (пробелы по краям не учитываются)
+func (r PositiveRule) Match(value string) bool {
+	return slices.Contains(r.Values, strings.TrimSpace(value))
+}
+
+// ForColumn возвращает правило для колонки
+func (rules PositiveRules) ForColumn(header string) (PositiveRule, bool) {
+	for _, rule := range rules {
+		if rule.Column == header {
+			return rule, true
+		}
+	}
+	return PositiveRule{}, false
+}
+
+// IsPositive — есть ли в строке хотя бы одно положительное значение
+func (rules PositiveRules) IsPositive(row XLSRow) bool {
+	for _, rule := range rules {
+		if rule.Match(row.Value(rule.Column)) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge — правила rules с добавленными other; правило other для уже
+// описанной колонки заменяет прежнее
+func (rules PositiveRules) merge(other PositiveRules) PositiveRules {
+	out := slices.Clone(rules)
+	for _, rule := range other {
+		i := slices.IndexFunc(out, func(r PositiveRule) bool { return r.Column == rule.Column })
+		if i >= 0 {
+			out[i] = rule
+		} else {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
+
+// validate проверяет, что все правила ссылаются на известные колонки
+func (rules PositiveRules) validate() error {
+	for _, rule := range rules {
+		if _, ok := findXLSColumn(rule.Column); !ok {
+			return fmt.Errorf("правило положительного результата: неизвестная колонка %q", rule.Column)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ===== ФОРМАТ ФАЙЛА ОТВЕТА ИБД-Ф =====
+
+// tableFormat — формат файла с ответом, определяется по содержимому,
+// а не по расширению: HTML и CSV часто сохраняют как .xls
+type tableFormat int
+
+const (
+	formatCSV  tableFormat = iota // все, что не распознано иначе
+	formatXLS                     // BIFF8 в составном файле
+	formatXLSX                    // zip-архив Office Open XML
+	formatHTML
+)
+
+func (f tableFormat) String() string {
+	switch f {
+	case formatXLS:
+		return "xls"
+	case formatXLSX:
+		return "xlsx"
+	case formatHTML:
+		return "html"
+	default:
+		return "csv"
+	}
+}
+
+// байт в начале файла, которых достаточно для определения формата
+const sniffSize = 1024
+
+var zipSignature = []byte("PK\x03\x04")
+
+// sniffTableFormat читает начало файла и определяет формат
+func sniffTableFormat(filename string) (tableFormat, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("файл %s пуст", filename)
+	}
+	return detectTableFormat(head[:n]), nil
+}
+
+func detectTableFormat(head []byte) tableFormat {
+	switch {
+	case bytes.HasPrefix(head, cfbSignature):
+		return formatXLS
+	case bytes.HasPrefix(head, zipSignature):
+		return formatXLSX
+	}
+
+	text := bytes.ToLower(bytes.TrimLeft(bytes.TrimPrefix(head, bomUTF8), " \t\r\n"))
+	if bytes.HasPrefix(text, []byte("<")) ||
+		bytes.Contains(text, []byte("<html")) || bytes.Contains(text, []byte("<table")) {
+		return formatHTML
+	}
+	return formatCSV
+}
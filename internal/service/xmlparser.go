@@ -1,23 +1,33 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
 // ===== СТРУКТУРЫ =====
 
-type XmlParser struct{}
+type XmlParser struct {
+	cfg *Config
+	log *slog.Logger
+}
 
 func NewXMLParser() *XmlParser {
-	return &XmlParser{}
+	return NewXMLParserWithConfig(DefaultConfig())
+}
+
+func NewXMLParserWithConfig(cfg *Config) *XmlParser {
+	return &XmlParser{cfg: cfg, log: discardLogger}
 }
 
 type List struct {
@@ -32,18 +42,111 @@ type Document struct {
 
 type RequestInfo struct {
 	ConvictionPerson ConvictionPerson `xml:"ConvictionPerson"`
+	RequestDate      string           `xml:"RequestDate"`    // дата заявления
+	RequestPurpose   string           `xml:"RequestPurpose"` // цель получения справки
 }
 
 type ConvictionPerson struct {
-	CPSurname    string   `xml:"CPSurname"`
-	CPName       string   `xml:"CPName"`
-	CPPatronymic string   `xml:"CPPatronymic"`
-	CPBirthday   string   `xml:"CPBirthday"`
-	CPLastFIO    *LastFIO `xml:"CPLastFIO"`
+	CPSurname    string       `xml:"CPSurname"`
+	CPName       string       `xml:"CPName"`
+	CPPatronymic string       `xml:"CPPatronymic"`
+	CPBirthday   string       `xml:"CPBirthday"`
+	CPBirthPlace string       `xml:"CPBirthPlace"`  // место рождения
+	CPRegAddress string       `xml:"CPRegAddress"`  // адрес регистрации
+	CPIdentity   *IdentityDoc `xml:"CPIdentityDoc"` // документ, удостоверяющий личность
+	CPLastFIO    []LastFIO    `xml:"CPLastFIO"`
 }
 
+type IdentityDoc struct {
+	CPDocType      string `xml:"CPDocType"`
+	CPDocSeries    string `xml:"CPDocSeries"`
+	CPDocNumber    string `xml:"CPDocNumber"`
+	CPDocIssueDate string `xml:"CPDocIssueDate"`
+	CPDocIssuer    string `xml:"CPDocIssuer"`
+}
+
+// String собирает реквизиты документа в одну строку для отчета
+func (d *IdentityDoc) String() string {
+	if d == nil {
+		return ""
+	}
+
+	var parts []string
+	if number := strings.TrimSpace(d.CPDocSeries + " " + d.CPDocNumber); number != "" {
+		parts = append(parts, strings.TrimSpace(d.CPDocType+" "+number))
+	} else if d.CPDocType != "" {
+		parts = append(parts, d.CPDocType)
+	}
+	if d.CPDocIssuer != "" {
+		parts = append(parts, "выдан "+d.CPDocIssuer)
+	}
+	if d.CPDocIssueDate != "" {
+		parts = append(parts, d.CPDocIssueDate)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DocumentDetails — дополнительные сведения из XML для отчета
+type DocumentDetails struct {
+	BirthPlace     string `json:"birth_place"`     // Место рождения
+	RegAddress     string `json:"reg_address"`     // Адрес регистрации
+	IdentityDoc    string `json:"identity_doc"`    // Документ
+	RequestDate    string `json:"request_date"`    // Дата заявления
+	RequestPurpose string `json:"request_purpose"` // Цель получения справки
+}
+
+// Details возвращает дополнительные сведения документа
+func (d Document) Details() *DocumentDetails {
+	p := d.RequestInfo.ConvictionPerson
+	return &DocumentDetails{
+		BirthPlace:     p.CPBirthPlace,
+		RegAddress:     p.CPRegAddress,
+		IdentityDoc:    p.CPIdentity.String(),
+		RequestDate:    d.RequestInfo.RequestDate,
+		RequestPurpose: d.RequestInfo.RequestPurpose,
+	}
+}
+
+// прежние ФИО, незаполненные части совпадают с текущими
 type LastFIO struct {
-	CPLSurname string `xml:"CPLSurname"`
+	CPLSurname    string `xml:"CPLSurname"`
+	CPLName       string `xml:"CPLName"`
+	CPLPatronymic string `xml:"CPLPatronymic"`
+}
+
+type FIO struct {
+	Surname    string
+	Name       string
+	Patronymic string
+}
+
+// FullNames возвращает текущее ФИО и все прежние полные ФИО без повторов
+func (p ConvictionPerson) FullNames() []FIO {
+	current := FIO{Surname: p.CPSurname, Name: p.CPName, Patronymic: p.CPPatronymic}
+	names := []FIO{current}
+
+	for _, last := range p.CPLastFIO {
+		if last.CPLSurname == "" && last.CPLName == "" && last.CPLPatronymic == "" {
+			continue
+		}
+
+		fio := current
+		if last.CPLSurname != "" {
+			fio.Surname = last.CPLSurname
+		}
+		if last.CPLName != "" {
+			fio.Name = last.CPLName
+		}
+		if last.CPLPatronymic != "" {
+			fio.Patronymic = last.CPLPatronymic
+		}
+
+		if !slices.Contains(names, fio) {
+			names = append(names, fio)
+		}
+	}
+
+	return names
 }
 
 type XLSRow struct {
@@ -64,84 +167,175 @@ type XLSRow struct {
 	PassportRF      string // Паспорт РФ
 	DeportationMode string // Реж.высылки
 	DocumentNumber  string // № документа (из XML)
+
+	Details    *DocumentDetails // сведения из XML, если строка сопоставлена
+	Ambiguous  int              // сколько документов подошло, если больше одного
+	Candidates []MatchCandidate // похожие документы, если точного совпадения нет
+}
+
+// статусы строки ИБД-Ф после сравнения
+const (
+	StatusMatched = "Найден"
+	StatusReview  = "Требует проверки"
+	StatusOrphan  = "Нет в XML"
+)
+
+// Status — результат сравнения строки для отчета
+func (r XLSRow) Status() string {
+	switch {
+	case r.Ambiguous > 0:
+		return fmt.Sprintf("Неоднозначно (%d док.)", r.Ambiguous)
+	case r.DocumentNumber != "":
+		return StatusMatched
+	case len(r.Candidates) > 0:
+		return StatusReview
+	default:
+		return StatusOrphan
+	}
 }
 
-// меняем дату
+// Orphan — строка не соответствует ни одному документу XML
+// (например, ответ из другой партии)
+func (r XLSRow) Orphan() bool {
+	return r.Status() == StatusOrphan
+}
+
+// меняем дату в формат строки запроса yyyy;mm;dd
 
 func convertDate(d string) string {
-	parts := strings.Split(d, ".")
-	if len(parts) != 3 {
+	date, err := ParseDate(d)
+	if err != nil {
 		return ""
 	}
-	return fmt.Sprintf("%s;%s;%s", parts[2], parts[1], parts[0])
+	return date.Line()
 }
 
 // ===== ПАРСИНГ XML =====
 
 func ParseXMLToLines(xmlData []byte) ([]string, error) {
-	var list List
-	err := xml.Unmarshal(xmlData, &list)
+	return ParseXMLReaderToLines(bytes.NewReader(xmlData))
+}
+
+func ParseXMLReaderToLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	err := DecodeDocuments(r, func(doc Document) error {
+		lines = append(lines, documentLines(doc)...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var lines []string
+	return lines, nil
+}
 
-	for _, doc := range list.Document {
-		p := doc.RequestInfo.ConvictionPerson
-		date := convertDate(p.CPBirthday)
+// строки запроса для одного документа: текущее ФИО и все прежние
+func documentLines(doc Document) []string {
+	p := doc.RequestInfo.ConvictionPerson
+	date := convertDate(p.CPBirthday)
 
-		// текущая фамилия
+	var lines []string
+	for _, fio := range p.FullNames() {
 		lines = append(lines,
 			fmt.Sprintf("%s;%s;%s;%s",
-				p.CPSurname, p.CPName, p.CPPatronymic, date))
-
-		// старая фамилия (если есть)
-		if p.CPLastFIO != nil && p.CPLastFIO.CPLSurname != "" {
-			lines = append(lines,
-				fmt.Sprintf("%s;%s;%s;%s",
-					p.CPLastFIO.CPLSurname, p.CPName, p.CPPatronymic, date))
-		}
+				fio.Surname, fio.Name, fio.Patronymic, date))
 	}
 
-	return lines, nil
+	return lines
 }
 
 func (x *XmlParser) ParseXMLToFile(filename string) (string, error) {
-	data, err := os.ReadFile(filename)
+	return x.ParseXMLToFileContext(context.Background(), filename)
+}
+
+// ParseXMLToFileContext — ParseXMLToFile с отменой и ходом выполнения через ctx
+func (x *XmlParser) ParseXMLToFileContext(ctx context.Context, filename string) (string, error) {
+	file, err := os.Open(filename)
 	if err != nil {
 		return "", err
 	}
+	defer file.Close()
 
-	lines, err := ParseXMLToLines(data)
-	if err != nil {
-		return "", err
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
 	}
 
 	var result strings.Builder
-	for _, line := range lines {
-		result.WriteString(line)
-		result.WriteString("\n")
+	docs := 0
+	counter := &countingReader{r: file}
+	err = DecodeDocumentsContext(ctx, counter, func(doc Document) error {
+		docs++
+		progress(ctx, Progress{Stage: StageParse, Done: docs, Bytes: counter.n, Size: size})
+		for _, line := range documentLines(doc) {
+			result.WriteString(line)
+			result.WriteString("\n")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 	return result.String(), nil
 }
 
 func ReadXLSFile(filename string) ([]XLSRow, error) {
-	// пробуем как excel
+	return NewXMLParser().ReadXLSFile(filename)
+}
+
+// ReadXLSFile читает ответ ИБД-Ф: .xls, .xlsx, HTML или CSV/TSV.
+// Формат определяется по содержимому, колонки ищутся по заголовкам из настроек.
+func (x *XmlParser) ReadXLSFile(filename string) ([]XLSRow, error) {
+	return x.ReadXLSFileContext(context.Background(), filename)
+}
+
+// ReadXLSFileContext — ReadXLSFile с отменой и ходом выполнения через ctx
+func (x *XmlParser) ReadXLSFileContext(ctx context.Context, filename string) ([]XLSRow, error) {
+	format, err := sniffTableFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	x.log.Info("чтение ответа ИБД-Ф", "file", filepath.Base(filename), "format", format)
+	progress(ctx, Progress{Stage: StageRead})
+
+	var rows [][]string
+	switch format {
+	case formatXLS:
+		rows, err = readXLSRows(filename)
+	case formatXLSX:
+		rows, err = readExcelRows(filename)
+	case formatHTML:
+		rows, err = readHTMLTable(filename, x.cfg.Columns)
+	default:
+		rows, err = readCSVTable(filename)
+	}
+	if err != nil {
+		x.log.Error("не удалось прочитать ответ ИБД-Ф", "format", format, "err", err)
+		return nil, err
+	}
+
+	return x.parseRowsToXLSRows(ctx, rows)
+}
+
+// значения ячеек без числового формата: дата — номер дня Excel (31111),
+// а не "03-05-85", как ее показывает формат ячейки
+var rawCells = excelize.Options{RawCellValue: true}
+
+func readExcelRows(filename string) ([][]string, error) {
 	f, err := excelize.OpenFile(filename)
 	if err != nil {
-		fmt.Printf("Не удалось открыть как Excel, пробуем как HTML: %v\n", err)
-		// Если не получилось, пробуем прочитать как HTML
-		return readHTMLTable(filename)
+		return nil, err
 	}
 	defer f.Close()
 
 	// Логика для настоящих Excel файлов
-	rows, err := f.GetRows("Sheet1")
+	rows, err := f.GetRows("Sheet1", rawCells)
 	if err != nil {
 		sheets := f.GetSheetList()
 		if len(sheets) > 0 {
-			rows, err = f.GetRows(sheets[0])
+			rows, err = f.GetRows(sheets[0], rawCells)
 			if err != nil {
 				return nil, err
 			}
@@ -150,334 +344,119 @@ func ReadXLSFile(filename string) ([]XLSRow, error) {
 		}
 	}
 
-	return parseRowsToXLSRows(rows), nil
+	return rows, nil
 }
 
-// читаем  HTML-таблицу из файла
-func readHTMLTable(filename string) ([]XLSRow, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
-
-	content := string(data)
+// преобразуем строки в структуры XLSRow, колонки определяем по заголовку
+func (x *XmlParser) parseRowsToXLSRows(ctx context.Context, rows [][]string) ([]XLSRow, error) {
+	var xlsRows []XLSRow
 
-	trRegex := regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
-	tdRegex := regexp.MustCompile(`(?is)<t[dh][^>]*>(.*?)</t[dh]>`)
+	x.log.Debug("найдено строк в таблице", "rows", len(rows))
 
-	matches := trRegex.FindAllStringSubmatch(content, -1)
-	if matches == nil {
-		return nil, fmt.Errorf("таблица <tr>...</tr> не найдена в HTML")
+	mapping, err := findColumns(rows, x.cfg.Columns)
+	if err != nil {
+		return nil, err
 	}
+	// заголовки не содержат персональных данных
+	x.log.Debug("строка заголовка", "row", mapping.headerRow+1, "headers", rows[mapping.headerRow])
 
-	var rows [][]string
-
-	for _, trMatch := range matches {
-		trContent := trMatch[1]
-
-		var cells []string
-
-		tds := tdRegex.FindAllStringSubmatch(trContent, -1)
-		for _, td := range tds {
-			text := stripHTML(td[1])
-			text = strings.ReplaceAll(text, "&nbsp;", " ")
-			text = strings.TrimSpace(text)
-			cells = append(cells, text)
+	data := rows[mapping.headerRow+1:]
+	for i, row := range data {
+		if err := progressStep(ctx, StageRead, i+1, len(data)); err != nil {
+			return nil, err
 		}
 
-		if len(cells) > 0 {
-			rows = append(rows, cells)
-		}
-	}
-
-	if len(rows) == 0 {
-		return nil, fmt.Errorf("ячейки <td> не найдены в HTML таблице")
-	}
-
-	return parseRowsToXLSRows(rows), nil
-}
-
-func stripHTML(s string) string {
-	// убираем все теги
-	re := regexp.MustCompile(`(?is)<.*?>`)
-	return re.ReplaceAllString(s, "")
-}
-
-//преобразуем строки в структуры XLSRow
-func parseRowsToXLSRows(rows [][]string) []XLSRow {
-	var xlsRows []XLSRow
-
-	fmt.Printf("Найдено строк в таблице: %d\n", len(rows))
-
-	for i, row := range rows {
-		if i == 0 { // Пропускаем заголовки
-			fmt.Printf("Заголовки: %v\n", row)
+		// пустые строки не переносим
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
 			continue
 		}
 
-		fmt.Printf("Строка %d: %v\n", i, row)
-
-		// Заполняем структуру, проверяя границы массива
 		xlsRow := XLSRow{}
-		if len(row) > 0 {
-			xlsRow.Surname = row[0]
-		}
-		if len(row) > 1 {
-			xlsRow.Name = row[1]
-		}
-		if len(row) > 2 {
-			xlsRow.Patronymic = row[2]
-		}
-		if len(row) > 3 {
-			xlsRow.BirthYear = row[3]
-		}
-		if len(row) > 4 {
-			xlsRow.BirthMonth = row[4]
-		}
-		if len(row) > 5 {
-			xlsRow.BirthDay = row[5]
-		}
-		if len(row) > 6 {
-			xlsRow.Result = row[6]
-		}
-		if len(row) > 7 {
-			xlsRow.WantedPersons = row[7]
-		}
-		if len(row) > 8 {
-			xlsRow.OSKRegion = row[8]
-		}
-		if len(row) > 9 {
-			xlsRow.OSKGIAZ = row[9]
-		}
-		if len(row) > 10 {
-			xlsRow.AdminPracticeR = row[10]
-		}
-		if len(row) > 11 {
-			xlsRow.AdminPracticeF = row[11]
-		}
-		if len(row) > 12 {
-			xlsRow.ZAGSDeath = row[12]
-		}
-		if len(row) > 13 {
-			xlsRow.Restricted = row[13]
-		}
-		if len(row) > 14 {
-			xlsRow.PassportRF = row[14]
-		}
-		if len(row) > 15 {
-			xlsRow.DeportationMode = row[15]
+		for c, value := range row {
+			if field, ok := mapping.fields[c]; ok {
+				*field(&xlsRow) = value
+			}
 		}
 
+		x.log.Debug("строка ИБД-Ф", "row", i+1,
+			"fio", strings.TrimSpace(x.personal(xlsRow.Surname)+" "+x.personal(xlsRow.Name)+" "+x.personal(xlsRow.Patronymic)),
+			"birthday", x.personal(strings.Join([]string{xlsRow.BirthDay, xlsRow.BirthMonth, xlsRow.BirthYear}, ".")))
+
 		xlsRows = append(xlsRows, xlsRow)
 	}
 
-	fmt.Printf("Преобразовано строк в XLSRow: %d\n", len(xlsRows))
-	return xlsRows
+	x.log.Info("прочитан ответ ИБД-Ф", "rows", len(xlsRows))
+	return xlsRows, nil
 }
 
 func MatchXMLWithXLS(xmlData []byte, xlsRows []XLSRow) ([]XLSRow, error) {
-	var list List
-	err := xml.Unmarshal(xmlData, &list)
-	if err != nil {
-		return nil, err
-	}
-
-	xmlMap := make(map[string]string)
-
-	for _, doc := range list.Document {
-		p := doc.RequestInfo.ConvictionPerson
-		xmlDate := strings.Split(p.CPBirthday, ".")
-		if len(xmlDate) != 3 {
-			continue
-		}
+	return MatchXMLWithXLSContext(context.Background(), xmlData, xlsRows)
+}
 
-		// Ключ для поиска: Фамилия_Имя_Отчество_Год_Месяц_День
-		key1 := fmt.Sprintf("%s_%s_%s_%s_%s_%s",
-			p.CPSurname, p.CPName, p.CPPatronymic,
-			xmlDate[2], xmlDate[1], xmlDate[0])
-		xmlMap[key1] = doc.DocNumber
-
-		// Если есть старая фамилия, добавляем и ее
-		if p.CPLastFIO != nil && p.CPLastFIO.CPLSurname != "" {
-			key2 := fmt.Sprintf("%s_%s_%s_%s_%s_%s",
-				p.CPLastFIO.CPLSurname, p.CPName, p.CPPatronymic,
-				xmlDate[2], xmlDate[1], xmlDate[0])
-			xmlMap[key2] = doc.DocNumber
-		}
-	}
+// MatchXMLWithXLSContext — MatchXMLWithXLS с отменой через ctx
+func MatchXMLWithXLSContext(ctx context.Context, xmlData []byte, xlsRows []XLSRow) ([]XLSRow, error) {
+	return MatchXMLReaderWithXLSContext(ctx, bytes.NewReader(xmlData), xlsRows)
+}
 
-	// Сравниваем с XLS данными
-	for i, xlsRow := range xlsRows {
-		key := fmt.Sprintf("%s_%s_%s_%s_%s_%s",
-			xlsRow.Surname, xlsRow.Name, xlsRow.Patronymic,
-			xlsRow.BirthYear, xlsRow.BirthMonth, xlsRow.BirthDay)
+// MatchXMLFileWithXLS сравнивает строки XLS с XML, читая файл потоком
+func MatchXMLFileWithXLS(filename string, xlsRows []XLSRow) ([]XLSRow, error) {
+	return MatchXMLFileWithXLSContext(context.Background(), filename, xlsRows)
+}
 
-		if docNumber, exists := xmlMap[key]; exists {
-			xlsRows[i].DocumentNumber = docNumber
-		}
+// MatchXMLFileWithXLSContext — MatchXMLFileWithXLS с отменой через ctx
+func MatchXMLFileWithXLSContext(ctx context.Context, filename string, xlsRows []XLSRow) ([]XLSRow, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
 	}
+	defer file.Close()
 
-	return xlsRows, nil
+	return MatchXMLReaderWithXLSContext(ctx, file, xlsRows)
 }
 
-func ModifyXLSFile(filename string, xlsRows []XLSRow) error {
-	return createNewExcelFile(filename, xlsRows)
+func MatchXMLReaderWithXLS(r io.Reader, xlsRows []XLSRow) ([]XLSRow, error) {
+	return MatchXMLReaderWithXLSContext(context.Background(), r, xlsRows)
 }
 
-func createNewExcelFile(filename string, xlsRows []XLSRow) error {
-	f := excelize.NewFile()
-	mainSheet := "Sheet1"
-	positiveSheet := "Положительный результат"
-
-	f.NewSheet(positiveSheet)
-
-	// ===== Стили =====
-	headerStyle, _ := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Bold: true},
-		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-	})
-
-	gridStyle, _ := f.NewStyle(&excelize.Style{
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-	})
-
-	highlightStyle, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFFF00"}}, // желтый
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-	})
-
-	// Стиль для жирной темной ячейки "ДА"
-	darkDaCellStyle, _ := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Bold: true},
-		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FF6600"}}, // темно-оранжевый
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-		},
-	})
-
-	headers := []string{
-		"№ документа", "Фамилия", "Имя", "Отчество",
-		"Год рождения", "Месяц рождения", "День рождения",
-		"Результат", "Розыск лиц", "ОСК регион", "ОСК ГИАЦ",
-		"Адмпрактика регион", "Адмпрактика ФИС-М",
-		"ЗАГС рег.смерти", "Запретники", "Паспорт РФ", "Реж.высылки",
-	}
-
-	for i, header := range headers {
-		cellMain, _ := excelize.CoordinatesToCellName(i+1, 1)
-		cellPos, _ := excelize.CoordinatesToCellName(i+1, 1)
-		f.SetCellValue(mainSheet, cellMain, header)
-		f.SetCellValue(positiveSheet, cellPos, header)
-		f.SetCellStyle(mainSheet, cellMain, cellMain, headerStyle)
-		f.SetCellStyle(positiveSheet, cellPos, cellPos, headerStyle)
+// MatchXMLReaderWithXLSContext — MatchXMLReaderWithXLS с отменой через ctx
+func MatchXMLReaderWithXLSContext(ctx context.Context, r io.Reader, xlsRows []XLSRow) ([]XLSRow, error) {
+	batch, err := NewXMLParser().ReadBatchContext(ctx, r)
+	if err != nil {
+		return nil, err
 	}
 
-	mainRowIndex := 2
-	posRowIndex := 2
-
-	colIndices := map[string]int{
-		"Розыск лиц": 9,
-		"ОСК регион": 10,
-		"ОСК ГИАЦ":   11,
-	}
+	return batch.MatchContext(ctx, xlsRows)
+}
 
-	for _, row := range xlsRows {
-		isPositive := row.WantedPersons == "ДА" || row.OSKRegion == "ДА" || row.OSKGIAZ == "ДА"
-
-		rowData := []string{
-			row.DocumentNumber,
-			row.Surname,
-			row.Name,
-			row.Patronymic,
-			row.BirthYear,
-			row.BirthMonth,
-			row.BirthDay,
-			row.Result,
-			row.WantedPersons,
-			row.OSKRegion,
-			row.OSKGIAZ,
-			row.AdminPracticeR,
-			row.AdminPracticeF,
-			row.ZAGSDeath,
-			row.Restricted,
-			row.PassportRF,
-			row.DeportationMode,
-		}
+// ReportOptions — настройки отчета
+type ReportOptions struct {
+	ExtraColumns bool // добавить сведения из XML: место рождения, адрес, документ и т.д.
 
-		// --- Основной лист ---
-		for j, value := range rowData {
-			cell, _ := excelize.CoordinatesToCellName(j+1, mainRowIndex)
-			f.SetCellValue(mainSheet, cell, value)
-			f.SetCellStyle(mainSheet, cell, cell, gridStyle)
-
-			// Если ячейка в столбцах с "ДА", делаем ее темной и жирной
-			if (j+1 == colIndices["Розыск лиц"] && value == "ДА") ||
-				(j+1 == colIndices["ОСК регион"] && value == "ДА") ||
-				(j+1 == colIndices["ОСК ГИАЦ"] && value == "ДА") {
-				f.SetCellStyle(mainSheet, cell, cell, darkDaCellStyle)
-			}
-		}
+	// правила положительного результата, если не заданы — DefaultPositiveRules
+	PositiveRules PositiveRules
 
-		// Жёлтая подсветка всей строки на основном листе
-		startMain, _ := excelize.CoordinatesToCellName(1, mainRowIndex)
-		endMain, _ := excelize.CoordinatesToCellName(len(headers), mainRowIndex)
-		if isPositive {
-			f.SetCellStyle(mainSheet, startMain, endMain, highlightStyle)
-			for _, idx := range []int{colIndices["Розыск лиц"], colIndices["ОСК регион"], colIndices["ОСК ГИАЦ"]} {
-				if idx >= 1 && idx <= len(rowData) && rowData[idx-1] == "ДА" {
-					daCell, _ := excelize.CoordinatesToCellName(idx, mainRowIndex)
-					f.SetCellStyle(mainSheet, daCell, daCell, darkDaCellStyle)
-				}
-			}
-		}
+	Format string // формат файла: xlsx, csv, json, html (по умолчанию xlsx)
 
-		// --- Положительный результат ---
-		if isPositive {
-			for j, value := range rowData {
-				cell, _ := excelize.CoordinatesToCellName(j+1, posRowIndex)
-				f.SetCellValue(positiveSheet, cell, value)
-				f.SetCellStyle(positiveSheet, cell, cell, gridStyle)
-				if (j+1 == colIndices["Розыск лиц"] && value == "ДА") ||
-					(j+1 == colIndices["ОСК регион"] && value == "ДА") ||
-					(j+1 == colIndices["ОСК ГИАЦ"] && value == "ДА") {
-					f.SetCellStyle(positiveSheet, cell, cell, darkDaCellStyle)
-				}
-			}
-			posRowIndex++
-		}
+	OutPath      string // файл отчета (с расширением) или папка (создается), по умолчанию Dir
+	Dir          string // папка для отчетов (создается), по умолчанию рядом с файлом ИБД-Ф
+	NameTemplate string // шаблон имени, по умолчанию DefaultNameTemplate
+	XMLFile      string // XML выгрузка, для {xml} в шаблоне
+	BatchNumber  int    // номер партии (вкладки), для {batch} в шаблоне
+}
 
-		mainRowIndex++
+func (o ReportOptions) rules() PositiveRules {
+	if o.PositiveRules == nil {
+		return DefaultPositiveRules()
 	}
+	return o.PositiveRules
+}
 
-	// Ширина колонок
-	for i := 1; i <= len(headers); i++ {
-		col, _ := excelize.ColumnNumberToName(i)
-		f.SetColWidth(mainSheet, col, col, 15)
-		f.SetColWidth(positiveSheet, col, col, 15)
-	}
+// ModifyXLSFile создает отчет по умолчанию рядом с файлом ИБД-Ф
+func ModifyXLSFile(filename string, xlsRows []XLSRow) (*ReportResult, error) {
+	return ModifyXLSFileContext(context.Background(), filename, xlsRows)
+}
 
-	now := time.Now()
-	formattedTime := now.Format("02.01.2006_15-04-05")
-	dir := filepath.Dir(filename)
-	newFileName := filepath.Join(dir, "goususlugi_"+formattedTime+".xlsx")
-	return f.SaveAs(newFileName)
+// ModifyXLSFileContext — ModifyXLSFile с отменой через ctx
+func ModifyXLSFileContext(ctx context.Context, filename string, xlsRows []XLSRow) (*ReportResult, error) {
+	return CreateReportContext(ctx, filename, &MatchResult{Rows: xlsRows}, ReportOptions{})
 }
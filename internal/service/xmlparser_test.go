@@ -0,0 +1,47 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// дата рождения ячейкой с форматом даты в .xlsx: читается номер дня Excel,
+// а не отформатированный текст с двузначным годом
+func TestReadXLSFileDateCell(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ответ.xlsx")
+
+	f := excelize.NewFile()
+	headers := []any{"Фамилия", "Имя", "Отчество", "Год рождения", "Месяц рождения", "День рождения"}
+	f.SetSheetRow("Sheet1", "A1", &headers)
+	f.SetSheetRow("Sheet1", "A2", &[]any{"Иванов", "Иван", "Иванович"})
+	f.SetCellValue("Sheet1", "D2", time.Date(1985, 3, 5, 0, 0, 0, 0, time.UTC))
+	style, err := f.NewStyle(&excelize.Style{NumFmt: 14}) // mm-dd-yy
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetCellStyle("Sheet1", "D2", "D2", style)
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	rows, err := ReadXLSFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("строк %d, ожидалась 1", len(rows))
+	}
+
+	row := rows[0]
+	date, err := ParseDateParts(row.BirthYear, row.BirthMonth, row.BirthDay)
+	if err != nil {
+		t.Fatalf("дата %q: %v", row.BirthYear, err)
+	}
+	if got := date.Key(); got != "1985_03_05" {
+		t.Errorf("дата %q = %s, ожидалось 1985_03_05", row.BirthYear, got)
+	}
+}
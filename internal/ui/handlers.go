@@ -1,10 +1,14 @@
 package ui
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	fynedialog "fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/sqweek/dialog"
@@ -25,8 +29,9 @@ func OpenFileDialog() string {
 
 func OpenFileDialog1() string {
 	fileName, err := dialog.File().
-		Filter("xls files", "xls").
-		Title("Выберите файл xls").
+		Filter("Ответ ИБД-Ф (xls, xlsx, html, csv)", "xls", "xlsx", "htm", "html", "csv", "tsv", "txt").
+		Filter("Все файлы", "*").
+		Title("Выберите файл ответа ИБД-Ф").
 		Load()
 	if err != nil {
 		return ""
@@ -34,11 +39,42 @@ func OpenFileDialog1() string {
 	return fileName
 }
 
+// SaveReportDialog предлагает путь для отчета, по умолчанию — имя по шаблону
+// в папке из настроек или рядом с файлом ИБД-Ф. Пустая строка — отмена.
+func SaveReportDialog(xlsFile string, opts service.ReportOptions) (string, error) {
+	suggested, err := service.ReportPath(xlsFile, opts)
+	if err != nil {
+		return "", err
+	}
+	ext := strings.TrimPrefix(filepath.Ext(suggested), ".")
+
+	fileName, err := dialog.File().
+		Filter("Отчет ("+ext+")", ext).
+		SetStartDir(filepath.Dir(suggested)).
+		SetStartFile(filepath.Base(suggested)).
+		Title("Сохранить отчет").
+		Save()
+	if errors.Is(err, dialog.ErrCancelled) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	// расширение должно соответствовать формату отчета
+	if format := service.FormatFromPath(suggested); service.FormatFromPath(fileName) != format {
+		if service.FormatFromPath(fileName) != "" {
+			fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+		}
+		fileName += "." + ext
+	}
+	return fileName, nil
+}
+
 // Функция для создания содержимого вкладки аккордеона с кнопкой копирования
-func createTabContent(text string, win fyne.Window, notifier *Notifier, xmlFileName string) fyne.CanvasObject {
+func createTabContent(lines []string, tabNumber int, win fyne.Window, notifier *Notifier, parser *service.XmlParser, batch *service.Batch, options *compareOptions, ops *operations) fyne.CanvasObject {
 	
 	entry := widget.NewMultiLineEntry()
-	entry.SetText(text)
+	entry.SetText(strings.Join(lines, "\n"))
 	entry.SetMinRowsVisible(4)
 	entry.Wrapping = fyne.TextWrapWord
 
@@ -46,10 +82,11 @@ func createTabContent(text string, win fyne.Window, notifier *Notifier, xmlFileN
 	var mergeBtn *widget.Button
 
 	mergeBtn = widget.NewButtonWithIcon("Сравнить c ИБД-Ф", theme.SearchReplaceIcon(), func() {
-		notifier.Show("Сравнение и создание нового файла...")
+		reportOpts := options.Report()
+		reportOpts.XMLFile = batch.FileName
+		reportOpts.BatchNumber = tabNumber
 
 		go func() {
-			xmlFile := xmlFileName
 			xlsFile := OpenFileDialog1()
 			if xlsFile == "" {
 				fyne.Do(func() {
@@ -58,43 +95,63 @@ func createTabContent(text string, win fyne.Window, notifier *Notifier, xmlFileN
 				return
 			}
 
-			// Чтение XML и XLS
-			xmlData, err := os.ReadFile(xmlFile)
+			ctx, done := ops.Start()
+			defer done()
+
+			// Чтение XLS
+			xlsRows, err := parser.ReadXLSFileContext(ctx, xlsFile)
 			if err != nil {
 				fyne.Do(func() {
-					notifier.Show("Ошибка чтения XML: " + err.Error())
+					notifier.Show(errorText("Ошибка чтения XLS: ", err))
 				})
 				return
 			}
 
-			xlsRows, err := service.ReadXLSFile(xlsFile)
+			// Сравнение с уже разобранной выгрузкой, без ответа ищем среди строк вкладки
+			res, err := batch.CompareContext(ctx, xlsRows, lines)
 			if err != nil {
 				fyne.Do(func() {
-					notifier.Show("Ошибка чтения XLS: " + err.Error())
+					notifier.Show(errorText("Ошибка сравнения: ", err))
 				})
 				return
 			}
 
-			// Сравнение
-			matchedRows, err := service.MatchXMLWithXLS(xmlData, xlsRows)
+			// Куда сохранить отчет
+			outPath, err := SaveReportDialog(xlsFile, reportOpts)
 			if err != nil {
 				fyne.Do(func() {
-					notifier.Show("Ошибка сравнения: " + err.Error())
+					notifier.Show("Отчет не сохранен: " + err.Error())
+				})
+				return
+			}
+			if outPath == "" {
+				fyne.Do(func() {
+					notifier.Show("Сохранение отчета отменено")
 				})
 				return
 			}
+			reportOpts.OutPath = outPath
 
 			// Мутим новый файл
-			err = service.ModifyXLSFile(xlsFile, matchedRows)
+			report, err := service.CreateReportContext(ctx, xlsFile, res, reportOpts)
 			if err != nil {
 				fyne.Do(func() {
-					notifier.Show("Ошибка создания файла: " + err.Error())
+					notifier.Show(errorText("Ошибка создания файла: ", err))
 				})
 				return
 			}
 
+			summary := res.Summary()
 			fyne.Do(func() {
-				notifier.Show("Новый файл успешно создан!")
+				notifier.Show("Новый файл успешно создан: " + report.Path)
+				notifier.Show(summary.String())
+				if summary.Orphans > 0 {
+					notifier.Show(fmt.Sprintf("Внимание: %d строк ИБД-Ф нет в XML — возможно, ответ из другой партии", summary.Orphans))
+				}
+				if len(res.Missing) > 0 {
+					showMissingLines(win, notifier, res.MissingLines())
+				}
+				showReportResult(win, notifier, report)
 			})
 
 		}()
@@ -116,3 +173,45 @@ func createTabContent(text string, win fyne.Window, notifier *Notifier, xmlFileN
 
 	return container.NewBorder(nil, buttonsContainer, nil, nil, entry)
 }
+
+// окно с итогами отчета и кнопками открыть файл / показать в папке
+func showReportResult(win fyne.Window, notifier *Notifier, report *service.ReportResult) {
+	openBtn := widget.NewButtonWithIcon("Открыть файл", theme.FileIcon(), func() {
+		if err := openPath(report.Path); err != nil {
+			notifier.Show("Не удалось открыть файл: " + err.Error())
+		}
+	})
+	folderBtn := widget.NewButtonWithIcon("Показать в папке", theme.FolderOpenIcon(), func() {
+		if err := showInFolder(report.Path); err != nil {
+			notifier.Show("Не удалось открыть папку: " + err.Error())
+		}
+	})
+
+	pathLabel := widget.NewLabel(report.Path)
+	pathLabel.Wrapping = fyne.TextWrapBreak
+
+	content := container.NewVBox(
+		pathLabel,
+		widget.NewLabel(report.String()),
+		container.NewGridWithColumns(2, openBtn, folderBtn),
+	)
+	fynedialog.NewCustom("Отчет сохранен", "Закрыть", content, win).Show()
+}
+
+// окно со строками запроса, на которые ИБД-Ф не ответил, для повторной отправки
+func showMissingLines(win fyne.Window, notifier *Notifier, lines []string) {
+	entry := widget.NewMultiLineEntry()
+	entry.SetText(strings.Join(lines, "\n"))
+	entry.SetMinRowsVisible(8)
+
+	copyBtn := widget.NewButtonWithIcon("Копировать строки", theme.ContentCopyIcon(), func() {
+		win.Clipboard().SetContent(entry.Text)
+		notifier.Show("Строки скопированы в буфер обмена")
+	})
+
+	title := fmt.Sprintf("Нет ответа ИБД-Ф: %d строк", len(lines))
+	content := container.NewBorder(nil, copyBtn, nil, nil, entry)
+	d := fynedialog.NewCustom(title, "Закрыть", content, win)
+	d.Resize(fyne.NewSize(600, 400))
+	d.Show()
+}
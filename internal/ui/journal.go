@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	fynedialog "fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"nabievarthur/GOsuslugiXML/internal/service"
+)
+
+// сколько байт с конца журнала показываем в окне
+const journalTail = 64 << 10
+
+// showJournal — окно «Журнал» с концом файла журнала
+func showJournal(win fyne.Window, notifier *Notifier) {
+	path, err := service.LogPath()
+	if err != nil {
+		notifier.Show("Журнал недоступен: " + err.Error())
+		return
+	}
+
+	entry := widget.NewMultiLineEntry()
+	entry.Wrapping = fyne.TextWrapOff
+	entry.SetMinRowsVisible(16)
+
+	refresh := func() {
+		text, err := readTail(path, journalTail)
+		if err != nil {
+			text = "Журнал пуст или недоступен: " + err.Error()
+		}
+		entry.SetText(text)
+		entry.CursorRow = strings.Count(text, "\n")
+	}
+	refresh()
+
+	refreshBtn := widget.NewButtonWithIcon("Обновить", theme.ViewRefreshIcon(), refresh)
+	folderBtn := widget.NewButtonWithIcon("Открыть папку", theme.FolderOpenIcon(), func() {
+		if err := openPath(filepath.Dir(path)); err != nil {
+			notifier.Show("Не удалось открыть папку: " + err.Error())
+		}
+	})
+
+	content := container.NewBorder(nil, container.NewGridWithColumns(2, refreshBtn, folderBtn), nil, nil, entry)
+	d := fynedialog.NewCustom("Журнал", "Закрыть", content, win)
+	d.Resize(fyne.NewSize(800, 500))
+	d.Show()
+}
+
+// readTail читает не больше n последних байт файла
+func readTail(path string, n int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(info.Size()-n, 0)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	// первая строка обрезана посередине
+	if offset > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return string(data), nil
+}
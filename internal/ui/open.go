@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// openPath открывает файл или папку программой по умолчанию
+func openPath(path string) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	// URL собирается из пути напрямую: # и % в имени файла не разбираются
+	// как якорь и экранирование; у пути с диском (C:/...) нужен ведущий /
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return fyne.CurrentApp().OpenURL(&url.URL{Scheme: "file", Path: p})
+}
+
+// showInFolder открывает папку с файлом, в Windows файл сразу выделен
+func showInFolder(path string) error {
+	if runtime.GOOS == "windows" {
+		return exec.Command("explorer", "/select,", path).Start()
+	}
+	return openPath(filepath.Dir(path))
+}
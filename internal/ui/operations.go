@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"nabievarthur/GOsuslugiXML/internal/service"
+)
+
+// как часто обновлять полосу хода выполнения
+const progressInterval = 100 * time.Millisecond
+
+// operations — долгие операции (разбор XML, чтение ответа, сравнение,
+// запись отчета): полоса хода выполнения и кнопка «Отмена», видимые,
+// пока хотя бы одна операция выполняется
+type operations struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	stop    context.CancelFunc
+	running int
+	shown   time.Time // последнее обновление полосы
+
+	label *widget.Label
+	bar   *widget.ProgressBar
+	btn   *widget.Button
+	box   *fyne.Container
+}
+
+func newOperations() *operations {
+	o := &operations{
+		label: widget.NewLabel(""),
+		bar:   widget.NewProgressBar(),
+	}
+	o.btn = widget.NewButtonWithIcon("Отмена", theme.CancelIcon(), o.Cancel)
+	o.btn.Importance = widget.DangerImportance
+
+	o.box = container.NewBorder(nil, nil, o.label, o.btn, o.bar)
+	o.box.Hide()
+	return o
+}
+
+func (o *operations) Widget() fyne.CanvasObject {
+	return o.box
+}
+
+// Start регистрирует операцию и возвращает ее контекст, через который
+// сервис сообщает о ходе выполнения; done нужно вызвать по завершении
+func (o *operations) Start() (ctx context.Context, done func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.ctx == nil {
+		ctx, stop := context.WithCancel(context.Background())
+		o.ctx, o.stop = service.WithProgress(ctx, o.progress), stop
+	}
+	o.running++
+	fyne.Do(func() {
+		o.label.SetText("Выполнение...")
+		o.bar.SetValue(0)
+		o.box.Show()
+	})
+
+	var once sync.Once
+	return o.ctx, func() {
+		once.Do(func() {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+
+			o.running--
+			if o.running == 0 {
+				// освобождаем контекст, следующая операция получит новый
+				o.reset()
+				fyne.Do(o.box.Hide)
+			}
+		})
+	}
+}
+
+// progress показывает событие сервиса, не чаще progressInterval,
+// начало и конец этапа — всегда
+func (o *operations) progress(p service.Progress) {
+	o.mu.Lock()
+	now := time.Now()
+	edge := p.Done <= 1 || (p.Total > 0 && p.Done == p.Total)
+	if !edge && now.Sub(o.shown) < progressInterval {
+		o.mu.Unlock()
+		return
+	}
+	o.shown = now
+	o.mu.Unlock()
+
+	text, value := p.String(), p.Fraction()
+	fyne.Do(func() {
+		o.label.SetText(text)
+		o.bar.SetValue(value)
+	})
+}
+
+// Cancel отменяет все выполняющиеся операции
+func (o *operations) Cancel() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.reset()
+}
+
+func (o *operations) reset() {
+	if o.stop != nil {
+		o.stop()
+		o.ctx, o.stop = nil, nil
+	}
+}
+
+// errorText — текст ошибки для уведомления, отмена пользователем не ошибка
+func errorText(prefix string, err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "Операция отменена"
+	}
+	return prefix + err.Error()
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"nabievarthur/GOsuslugiXML/internal/service"
+)
+
+// настройки сравнения, общие для всех вкладок аккордеона
+type compareOptions struct {
+	cfg          *service.Config
+	extraColumns *widget.Check
+	format       *widget.Select
+	box          *fyne.Container
+}
+
+func newCompareOptions(cfg *service.Config) *compareOptions {
+	o := &compareOptions{
+		cfg:          cfg,
+		extraColumns: widget.NewCheck("Добавить в отчет сведения из XML (место рождения, адрес, документ)", nil),
+	}
+	o.format = widget.NewSelect(service.ReportFormats(), nil)
+	o.format.SetSelected(service.FormatExcel)
+	o.box = container.NewVBox(
+		o.extraColumns,
+		container.NewHBox(widget.NewLabel("Формат отчета:"), o.format),
+	)
+	return o
+}
+
+func (o *compareOptions) Widget() *fyne.Container {
+	return o.box
+}
+
+// Report возвращает настройки отчета по текущему состоянию виджетов
+func (o *compareOptions) Report() service.ReportOptions {
+	return service.ReportOptions{
+		ExtraColumns:  o.extraColumns.Checked,
+		PositiveRules: o.cfg.PositiveRules,
+		Format:        o.format.Selected,
+		Dir:           o.cfg.ReportDir,
+		NameTemplate:  o.cfg.ReportName,
+	}
+}
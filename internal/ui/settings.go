@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	fynedialog "fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"nabievarthur/GOsuslugiXML/internal/service"
+)
+
+// окно настроек разбиения на вкладки, сохраняются в файл настроек профиля;
+// onSave вызывается после успешного сохранения
+func showSettings(win fyne.Window, notifier *Notifier, cfg *service.Config, onSave func()) {
+	size := widget.NewEntry()
+	size.SetText(strconv.Itoa(cfg.Chunk.Size))
+	size.Validator = func(s string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("нужно целое число больше нуля")
+		}
+		return nil
+	}
+
+	keepDocs := widget.NewCheck("Не разделять строки одного документа (текущее и прежние ФИО)", nil)
+	keepDocs.SetChecked(cfg.Chunk.KeepDocuments)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Строк на вкладку", size),
+		widget.NewFormItem("", keepDocs),
+	}
+	items[0].HintText = "ограничение ИБД-Ф на один запрос"
+
+	d := fynedialog.NewForm("Настройки", "Сохранить", "Отмена", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(size.Text))
+		opts := service.ChunkOptions{Size: n, KeepDocuments: keepDocs.Checked}
+		if err := service.SaveUserChunkOptions(opts); err != nil {
+			notifier.Show("Настройки не сохранены: " + err.Error())
+			return
+		}
+		cfg.Chunk = opts
+		notifier.Show("Настройки сохранены")
+		onSave()
+	}, win)
+	d.Resize(fyne.NewSize(500, 200))
+	d.Show()
+}
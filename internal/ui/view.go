@@ -1,9 +1,10 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -14,9 +15,26 @@ import (
 )
 
 func BuildUI(win fyne.Window) fyne.CanvasObject {
-	parser := service.NewXMLParser()
 	notifier := NewNotifier()
 
+	cfg, err := service.LoadUserConfig()
+	if err != nil {
+		notifier.Show("Ошибка чтения настроек: " + err.Error())
+		cfg = service.DefaultConfig()
+	}
+	parser := service.NewXMLParserWithConfig(cfg)
+
+	// журнал в профиле пользователя, файл закрывается вместе с окном
+	if logger, logFile, err := service.OpenUserLog(cfg.Log); err != nil {
+		notifier.Show("Журнал недоступен: " + err.Error())
+	} else {
+		parser.SetLogger(logger)
+		// запись в закрытый журнал из незавершенной операции вернет ошибку, не панику
+		win.SetOnClosed(func() {
+			logFile.Close()
+		})
+	}
+
 	label1 := widget.NewLabel("Выберите файл XML")
 	label1.Alignment = fyne.TextAlignCenter
 
@@ -33,16 +51,59 @@ func BuildUI(win fyne.Window) fyne.CanvasObject {
 	)
 	separatorWithPadding.Hide()
 
+	// кнопка «Отмена» для долгих операций
+	ops := newOperations()
+
+	// настройки отчета для всех вкладок
+	options := newCompareOptions(cfg)
+	options.Widget().Hide()
+
+	// текущая партия, вкладки строятся по настройкам разбиения
+	var current *service.Batch
+	fillTabs := func() {
+		accordion.Items = nil
+		chunks := current.Chunks(cfg.Chunk)
+
+		// Создаем вкладки с группами строк
+		for _, tabLines := range chunks {
+			// Создаем вкладку с содержимым
+			tabNumber := len(accordion.Items) + 1
+			item := &widget.AccordionItem{
+				Title:  fmt.Sprintf("Часть %d (%d строк)", tabNumber, len(tabLines)),
+				Detail: createTabContent(tabLines, tabNumber, win, notifier, parser, current, options, ops),
+			}
+			accordion.Append(item)
+		}
+
+		if len(accordion.Items) > 0 {
+			accordion.Items[0].Open = true
+		}
+		accordion.Refresh()
+		win.Content().Refresh()
+
+		label2.SetText(fmt.Sprintf("Всего строк: %d, Вкладок: %d", len(current.Lines), len(accordion.Items)))
+		label2.Show()
+	}
+
 	var prepareBtn *widget.Button
 	//подготовка данных
 	prepareBtn = widget.NewButtonWithIcon("Подготовить", theme.ConfirmIcon(), func() {
-		notifier.Show("Выполнение...")
 		prepareBtn.Hide()
 
+		ctx, done := ops.Start()
 		go func() {
-			// Парсим
-			res, err := parser.ParseXMLToFile(label1.Text)
+			defer done()
 
+			// Парсим один раз, дальше все сравнения идут по этому снимку
+			batch, err := parser.LoadBatchContext(ctx, label1.Text)
+
+			if errors.Is(err, context.Canceled) {
+				fyne.Do(func() {
+					notifier.Show(errorText("", err))
+					prepareBtn.Show()
+				})
+				return
+			}
 			if err != nil {
 				fyne.Do(func() {
 					notifier.Show("Ошибка: " + err.Error())
@@ -51,54 +112,9 @@ func BuildUI(win fyne.Window) fyne.CanvasObject {
 			}
 
 			fyne.Do(func() {
-				accordion.Items = nil
-				// Делим на строки
-				lines := strings.Split(strings.TrimSpace(res), "\n")
-				totalLines := len(lines)
-
-				// Настройки
-				maxLinesPerTab := 500 // Максимальное количество строк на вкладку
-				currentLine := 0
-
-				// Создаем вкладки с группами строк
-				for currentLine < totalLines {
-					endLine := currentLine + maxLinesPerTab
-					if endLine > totalLines {
-						endLine = totalLines
-					}
-
-					//текст для текущей вкладки
-					tabLines := lines[currentLine:endLine]
-					tabText := strings.Join(tabLines, "\n")
-
-					// Создаем вкладку с содержимым
-					tabNumber := len(accordion.Items) + 1
-					linesInTab := len(tabLines)
-
-					item := &widget.AccordionItem{
-						Title:  fmt.Sprintf("Часть %d (%d строк)", tabNumber, linesInTab),
-						Detail: createTabContent(tabText, win, notifier, label1.Text),
-					}
-
-					accordion.Append(item)
-					currentLine = endLine
-				}
-
-				if len(accordion.Items) > 0 {
-					accordion.Items[0].Open = true
-
-					accordion.Refresh()
-
-					go func() {
-						fyne.Do(func() {
-							accordion.Refresh()
-							win.Content().Refresh()
-						})
-					}()
-				}
-
-				label2.SetText(fmt.Sprintf("Всего строк: %d, Вкладок: %d", totalLines, len(accordion.Items)))
-				label2.Show()
+				current = batch
+				fillTabs()
+				options.Widget().Show()
 				notifier.Show("Готово! Создано вкладок: " + strconv.Itoa(len(accordion.Items)))
 			})
 		}()
@@ -117,17 +133,34 @@ func BuildUI(win fyne.Window) fyne.CanvasObject {
 		label1.SetText(fileName)
 		prepareBtn.Show()
 
+		current = nil
 		accordion.Items = nil
 		accordion.Refresh()
 		label2.Hide()
+		options.Widget().Hide()
+	})
+
+	// журнал работы
+	journalBtn := widget.NewButtonWithIcon("Журнал", theme.ListIcon(), func() {
+		showJournal(win, notifier)
+	})
+
+	// размер вкладок, готовые вкладки перестраиваются сразу
+	settingsBtn := widget.NewButtonWithIcon("Настройки", theme.SettingsIcon(), func() {
+		showSettings(win, notifier, cfg, func() {
+			if current != nil {
+				fillTabs()
+			}
+		})
 	})
 
-	content := container.NewBorder(nil, notifier.Widget(), nil, nil,
+	content := container.NewBorder(nil, container.NewVBox(ops.Widget(), notifier.Widget()), nil, nil,
 		container.NewVBox(
 			label1,
-			openBtn,
+			container.NewBorder(nil, nil, nil, container.NewHBox(settingsBtn, journalBtn), openBtn),
 			prepareBtn,
 			separatorWithPadding,
+			options.Widget(),
 			accordion,
 			label2,
 		),